@@ -0,0 +1,238 @@
+package main
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"regexp"
+	"strings"
+)
+
+// --- Admin API ---
+
+// adminAPI serves /admin/targets for managing targets at runtime. Changes are
+// applied to the registry immediately and persisted in the config store.
+//
+//	GET    /admin/targets              list targets
+//	POST   /admin/targets              create a target
+//	GET    /admin/targets/{id}         show a target
+//	PUT    /admin/targets/{id}         replace a target's configuration
+//	DELETE /admin/targets/{id}         delete a target
+//	POST   /admin/targets/{id}/disable stop forwarding without deleting
+//	POST   /admin/targets/{id}/enable  resume forwarding
+//	POST   /admin/targets/{id}/test    send one test event per known action
+//	GET    /admin/history              search the event history
+type adminAPI struct {
+	token    string // ADMIN_TOKEN, sent as bearer token or Basic auth password
+	registry *registry
+	store    *configStore
+}
+
+var identifierPattern = regexp.MustCompile(`^[a-z0-9]+(-[a-z0-9]+)*$`)
+
+// maxAdminBodyBytes bounds the size of admin request bodies.
+const maxAdminBodyBytes = 1 << 20
+
+// adminTarget is the admin API view of a target.
+type adminTarget struct {
+	targetConfig
+	Path string `json:"path"` // Path without the token prefix
+}
+
+func newAdminTarget(t *target) adminTarget {
+	cfg := t.Config
+	cfg.Type = t.Type
+	return adminTarget{targetConfig: cfg, Path: "/" + t.Identifier}
+}
+
+func (a *adminAPI) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if !adminAuthorized(r, a.token) {
+		w.Header().Set("WWW-Authenticate", `Bearer realm="fizzy-webhook-proxy"`)
+		writeJSONError(w, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+
+	if r.URL.Path == "/admin/history" {
+		serveHistory(w, r)
+		return
+	}
+
+	rest := strings.TrimPrefix(r.URL.Path, "/admin/targets")
+	if rest == r.URL.Path {
+		writeJSONError(w, http.StatusNotFound, "not found")
+		return
+	}
+	parts := strings.Split(strings.Trim(rest, "/"), "/")
+
+	switch {
+	case parts[0] == "":
+		a.handleCollection(w, r)
+	case len(parts) == 1:
+		a.handleTarget(w, r, parts[0])
+	case len(parts) == 2:
+		a.handleTargetAction(w, r, parts[0], parts[1])
+	default:
+		writeJSONError(w, http.StatusNotFound, "not found")
+	}
+}
+
+func (a *adminAPI) handleCollection(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		targets := a.registry.list()
+		views := make([]adminTarget, 0, len(targets))
+		for _, t := range targets {
+			views = append(views, newAdminTarget(t))
+		}
+		writeJSON(w, http.StatusOK, views)
+
+	case http.MethodPost:
+		cfg, err := decodeTargetConfig(r)
+		if err != nil {
+			writeJSONError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		if a.registry.get(cfg.Identifier) != nil {
+			writeJSONError(w, http.StatusConflict, fmt.Sprintf("target %q already exists", cfg.Identifier))
+			return
+		}
+		a.save(w, cfg, http.StatusCreated)
+
+	default:
+		writeJSONError(w, http.StatusMethodNotAllowed, "method not allowed")
+	}
+}
+
+func (a *adminAPI) handleTarget(w http.ResponseWriter, r *http.Request, id string) {
+	t := a.registry.get(id)
+	if t == nil {
+		writeJSONError(w, http.StatusNotFound, fmt.Sprintf("target %q not found", id))
+		return
+	}
+
+	switch r.Method {
+	case http.MethodGet:
+		writeJSON(w, http.StatusOK, newAdminTarget(t))
+
+	case http.MethodPut:
+		cfg, err := decodeTargetConfig(r)
+		if err != nil {
+			writeJSONError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		if cfg.Identifier != "" && cfg.Identifier != id {
+			writeJSONError(w, http.StatusBadRequest, "identifier cannot be changed; delete and re-create the target")
+			return
+		}
+		cfg.Identifier = id
+		a.save(w, cfg, http.StatusOK)
+
+	case http.MethodDelete:
+		if err := a.store.remove(id); err != nil {
+			log.Printf("admin: failed to persist deletion of %s: %v", id, err)
+			writeJSONError(w, http.StatusInternalServerError, "failed to persist change")
+			return
+		}
+		a.registry.remove(id)
+		log.Printf("admin: deleted target %s", id)
+		w.WriteHeader(http.StatusNoContent)
+
+	default:
+		writeJSONError(w, http.StatusMethodNotAllowed, "method not allowed")
+	}
+}
+
+func (a *adminAPI) handleTargetAction(w http.ResponseWriter, r *http.Request, id, action string) {
+	t := a.registry.get(id)
+	if t == nil {
+		writeJSONError(w, http.StatusNotFound, fmt.Sprintf("target %q not found", id))
+		return
+	}
+	if r.Method != http.MethodPost {
+		writeJSONError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+
+	if action == "test" {
+		writeJSON(w, http.StatusOK, sendTestEvents(r.Context(), *t))
+		return
+	}
+
+	cfg := t.Config
+	switch action {
+	case "disable":
+		cfg.Disabled = true
+	case "enable":
+		cfg.Disabled = false
+	default:
+		writeJSONError(w, http.StatusNotFound, "not found")
+		return
+	}
+	a.save(w, cfg, http.StatusOK)
+}
+
+// save builds the target from cfg, persists it and swaps it into the
+// registry. Nothing is changed if the configuration is invalid.
+func (a *adminAPI) save(w http.ResponseWriter, cfg targetConfig, status int) {
+	t, err := buildTarget(cfg)
+	if err != nil {
+		writeJSONError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if err := a.store.put(cfg); err != nil {
+		log.Printf("admin: failed to persist target %s: %v", cfg.Identifier, err)
+		writeJSONError(w, http.StatusInternalServerError, "failed to persist change")
+		return
+	}
+	a.registry.put(t)
+	log.Printf("admin: saved target %s -> %s (%s, disabled=%t)", t.Identifier, t.URL, t.Type, t.Disabled)
+
+	writeJSON(w, status, newAdminTarget(t))
+}
+
+func decodeTargetConfig(r *http.Request) (targetConfig, error) {
+	var cfg targetConfig
+	dec := json.NewDecoder(io.LimitReader(r.Body, maxAdminBodyBytes))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&cfg); err != nil {
+		return cfg, fmt.Errorf("invalid target json: %v", err)
+	}
+
+	cfg.Type = TargetType(strings.ToLower(string(cfg.Type)))
+	for i, method := range cfg.Auth {
+		cfg.Auth[i] = strings.ToLower(method)
+	}
+	if r.Method == http.MethodPost && !identifierPattern.MatchString(cfg.Identifier) {
+		return cfg, fmt.Errorf("identifier must be lowercase letters, digits and hyphens")
+	}
+	return cfg, nil
+}
+
+// adminAuthorized checks the admin token, sent either as a bearer token or as
+// the password of HTTP Basic auth.
+func adminAuthorized(r *http.Request, token string) bool {
+	given, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+	if !ok {
+		_, given, ok = r.BasicAuth()
+	}
+	return ok && subtle.ConstantTimeCompare([]byte(given), []byte(token)) == 1
+}
+
+// --- JSON Helpers ---
+
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(v); err != nil {
+		log.Printf("response encode error: %v", err)
+	}
+}
+
+func writeJSONError(w http.ResponseWriter, status int, message string) {
+	writeJSON(w, status, map[string]string{"error": message})
+}
@@ -0,0 +1,273 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+)
+
+// --- Per-Card Aggregation ---
+
+// aggregator holds events for a short window per card and merges everything
+// that happened to the same card into a single message.
+type aggregator struct {
+	mu      sync.Mutex
+	window  time.Duration
+	pending map[string][]event
+	emit    func(event)
+}
+
+func newAggregator(window time.Duration, emit func(event)) *aggregator {
+	return &aggregator{
+		window:  window,
+		pending: make(map[string][]event),
+		emit:    emit,
+	}
+}
+
+// Add holds ev until the card's window closes. It returns false when the
+// event does not belong to an identifiable card and must be sent directly.
+func (a *aggregator) Add(ev event) bool {
+	key := cardKey(ev.Payload)
+	if key == "" {
+		return false
+	}
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	if _, open := a.pending[key]; !open {
+		// The window starts with the first event so latency stays bounded
+		// even if the card keeps changing.
+		time.AfterFunc(a.window, func() { a.close(key) })
+	}
+	a.pending[key] = append(a.pending[key], ev)
+	return true
+}
+
+func (a *aggregator) close(key string) {
+	a.mu.Lock()
+	events := a.pending[key]
+	delete(a.pending, key)
+	a.mu.Unlock()
+
+	if len(events) > 0 {
+		a.emit(mergeEvents(events))
+	}
+}
+
+// mergeEvents combines events on the same card into one. The latest payload
+// is kept as the primary one; all payloads are kept in Merged.
+func mergeEvents(events []event) event {
+	if len(events) == 1 {
+		return events[0]
+	}
+
+	first, last := events[0], events[len(events)-1]
+	merged := event{
+		ID:       last.ID,
+		Payload:  last.Payload,
+		RawQuery: last.RawQuery,
+		Received: first.Received,
+	}
+
+	raw := make([]json.RawMessage, 0, len(events))
+	for _, ev := range events {
+		merged.Merged = append(merged.Merged, ev.Payload)
+		merged.MergedIDs = append(merged.MergedIDs, ev.IDs()...)
+		raw = append(raw, ev.Raw)
+	}
+	merged.Raw, _ = json.Marshal(raw)
+
+	return merged
+}
+
+// loadAggregator builds the aggregator for a target from its aggregation
+// window. It returns nil when aggregation is disabled.
+func loadAggregator(tc targetConfig, emit func(event)) (*aggregator, error) {
+	v := tc.AggregateWindow
+	if v == "" {
+		return nil, nil
+	}
+	window, err := time.ParseDuration(v)
+	if err != nil || window <= 0 {
+		return nil, fmt.Errorf("invalid aggregate window %q", v)
+	}
+	return newAggregator(window, emit), nil
+}
+
+// --- Merged Message Rendering ---
+
+// mergedSummary describes a sequence of events on one card, e.g.
+// "**Alice** created a card, assigned the card to **Bob** and moved the card
+// to **In Progress**". Consecutive events by the same actor share a clause.
+func mergedSummary(cat *catalog, payloads []FizzyPayload) string {
+	var clauses []string
+	var verbs []string
+	actor := ""
+
+	flushClause := func() {
+		if len(verbs) > 0 {
+			clauses = append(clauses, fmt.Sprintf("**%s** %s", actor, joinWithAnd(cat, verbs)))
+		}
+		verbs = nil
+	}
+
+	for _, f := range payloads {
+		if a := actorName(cat, f); a != actor {
+			flushClause()
+			actor = a
+		}
+		verb, _ := prettyAction(cat, f)
+		if len(verbs) == 0 || verbs[len(verbs)-1] != verb {
+			verbs = append(verbs, verb)
+		}
+	}
+	flushClause()
+
+	return strings.Join(clauses, "; ")
+}
+
+// mergedSubject prefers a real card title from any of the payloads.
+func mergedSubject(cat *catalog, payloads []FizzyPayload) string {
+	for _, f := range payloads {
+		if f.Eventable.Title != "" {
+			return f.Eventable.Title
+		}
+	}
+	return resolveSubject(cat, payloads[len(payloads)-1])
+}
+
+// mergedComments returns the comment bodies posted during the window.
+func mergedComments(cat *catalog, payloads []FizzyPayload) []string {
+	var comments []string
+	for _, f := range payloads {
+		if f.Eventable.Body.PlainText != "" {
+			comments = append(comments, fmt.Sprintf("**%s:** %s", actorName(cat, f), f.Eventable.Body.PlainText))
+		}
+	}
+	return comments
+}
+
+// mergedURL links to the card rather than to an individual comment when
+// one of the payloads carries the card number.
+func mergedURL(payloads []FizzyPayload) string {
+	for _, f := range payloads {
+		if f.Eventable.Number != 0 {
+			return resolveFizzyURL(f)
+		}
+	}
+	return resolveFizzyURL(payloads[len(payloads)-1])
+}
+
+func buildMergedMessage(cat *catalog, payloads []FizzyPayload) string {
+	last := payloads[len(payloads)-1]
+	_, emoji := prettyAction(cat, last)
+
+	var sb strings.Builder
+	sb.WriteString(fmt.Sprintf("### %s %s", emoji, mergedSubject(cat, payloads)))
+	sb.WriteString("\n\n")
+	sb.WriteString(mergedSummary(cat, payloads))
+
+	for _, c := range mergedComments(cat, payloads) {
+		sb.WriteString("\n\n> ")
+		sb.WriteString(c)
+	}
+
+	if last.Board.Name != "" {
+		sb.WriteString("\n\n" + cat.text("board_line", last.Board.Name))
+	}
+
+	sb.WriteString(fmt.Sprintf("\n\n[%s](%s)", cat.text("view_in_fizzy"), mergedURL(payloads)))
+
+	return sb.String()
+}
+
+// translateMerged renders an aggregated event in the target's format.
+func translateMerged(t target, ev event) ([]byte, error) {
+	payloads := ev.Merged
+	switch t.Type {
+	case TargetZulip:
+		return json.Marshal(ZulipPayload{Content: buildMergedMessage(t.Catalog, payloads)})
+	case TargetGotify:
+		return json.Marshal(GotifyPayload{
+			Message:  buildMergedMessage(t.Catalog, payloads),
+			Title:    "Fizzy: " + mergedSubject(t.Catalog, payloads),
+			Priority: 5,
+			Extras: map[string]interface{}{
+				"client::display": map[string]string{
+					"contentType": "text/markdown",
+				},
+			},
+		})
+	case TargetGoogleChat:
+		return translateMergedToGoogleChat(t.Catalog, payloads)
+	default:
+		return ev.Raw, nil
+	}
+}
+
+func translateMergedToGoogleChat(cat *catalog, payloads []FizzyPayload) ([]byte, error) {
+	last := payloads[len(payloads)-1]
+	_, emoji := prettyAction(cat, last)
+	subject := mergedSubject(cat, payloads)
+	summary := mergedSummary(cat, payloads)
+
+	// Cards cannot render mentions, so only the text above the card has them.
+	widgets := []Widget{
+		{TextParagraph: &TextParagraph{Text: mergedSummary(cat.withMentions(nil), payloads)}},
+	}
+	for _, c := range mergedComments(cat, payloads) {
+		widgets = append(widgets, Widget{TextParagraph: &TextParagraph{Text: c}})
+	}
+	if last.Board.Name != "" {
+		widgets = append(widgets, Widget{
+			DecoratedText: &DecoratedText{
+				TopLabel:  cat.text("board"),
+				Text:      last.Board.Name,
+				StartIcon: &Icon{KnownIcon: "TICKET"},
+			},
+		})
+	}
+	widgets = append(widgets, Widget{
+		ButtonList: &ButtonList{
+			Buttons: []Button{
+				{
+					Text:    cat.text("view_in_fizzy"),
+					Icon:    &Icon{KnownIcon: "OPEN_IN_NEW"},
+					OnClick: &OnClick{OpenLink: &OpenLink{URL: mergedURL(payloads)}},
+				},
+			},
+		},
+	})
+
+	card := CardV2{
+		CardID: fmt.Sprintf("fizzy-%d", time.Now().UnixNano()),
+		Card: Card{
+			Header: CardHeader{
+				Title:    subject,
+				Subtitle: cat.text("updates", len(payloads)),
+			},
+			Sections: []CardSection{{Widgets: widgets}},
+		},
+	}
+
+	return json.Marshal(GoogleChatPayload{
+		Text:    fmt.Sprintf("%s %s: %s", emoji, subject, summary),
+		CardsV2: []CardV2{card},
+	})
+}
+
+// joinWithAnd joins items as "a, b and c".
+func joinWithAnd(cat *catalog, items []string) string {
+	switch len(items) {
+	case 0:
+		return ""
+	case 1:
+		return items[0]
+	default:
+		return strings.Join(items[:len(items)-1], ", ") + " " + cat.text("and") + " " + items[len(items)-1]
+	}
+}
@@ -0,0 +1,295 @@
+package main
+
+import (
+	"crypto/subtle"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"sort"
+	"strings"
+	"time"
+)
+
+// --- Webhook Tokens ---
+
+// apiToken is a secret that Fizzy puts in front of a target's path:
+// /{secret}/{identifier}. Several tokens can be valid at once, so a token is
+// rotated by adding the new one, updating the webhooks in Fizzy and then
+// letting the old one expire.
+type apiToken struct {
+	Name    string
+	Secret  string
+	Targets []string  // Identifiers the token may post to; empty means all
+	Expires time.Time // Zero means the token does not expire
+}
+
+// tokens holds the webhook tokens loaded at startup.
+var tokens []apiToken
+
+// allows reports why the token may not post to identifier, or nil.
+func (tok apiToken) allows(identifier string, now time.Time) error {
+	if !tok.Expires.IsZero() && now.After(tok.Expires) {
+		return fmt.Errorf("token %s expired at %s", tok.Name, tok.Expires.Format(time.RFC3339))
+	}
+	if len(tok.Targets) == 0 {
+		return nil
+	}
+	for _, id := range tok.Targets {
+		if id == identifier {
+			return nil
+		}
+	}
+	return fmt.Errorf("token %s is not allowed to post to %s", tok.Name, identifier)
+}
+
+// findToken returns the token with the given secret. Every token is compared
+// in constant time so the lookup does not leak which prefix matched.
+func findToken(secret string) *apiToken {
+	var found *apiToken
+	for i := range tokens {
+		if subtle.ConstantTimeCompare([]byte(secret), []byte(tokens[i].Secret)) == 1 {
+			found = &tokens[i]
+		}
+	}
+	return found
+}
+
+// loadTokens reads webhook tokens from the environment:
+//
+//	TOKEN=secret                    the default token, named "default"
+//	TOKEN_{NAME}=secret             an additional token
+//	TOKEN[_{NAME}]_TARGETS=a,b      restrict the token to these identifiers
+//	TOKEN[_{NAME}]_EXPIRES=date     stop accepting the token after this time
+//
+// A target may be named TOKEN_{NAME} too; its TOKEN_{NAME}_* settings are
+// not tokens.
+func loadTokens() ([]apiToken, []error) {
+	var loaded []apiToken
+	var errs []error
+	targets := tokenTargetPrefixes()
+
+	for _, env := range os.Environ() {
+		key, secret, ok := strings.Cut(env, "=")
+		if !ok || secret == "" {
+			continue
+		}
+
+		var name string
+		switch {
+		case key == "TOKEN":
+			name = "default"
+		case strings.HasPrefix(key, "TOKEN_"):
+			rest := strings.TrimPrefix(key, "TOKEN_")
+			if isTokenAttribute(rest) || rest == "URL" || strings.HasSuffix(rest, "_URL") || isTargetSetting(key, targets) {
+				continue
+			}
+			name = pathIdentifier(rest)
+		default:
+			continue
+		}
+
+		tok := apiToken{
+			Name:    name,
+			Secret:  secret,
+			Targets: splitList(os.Getenv(key + "_TARGETS")),
+		}
+		if v := os.Getenv(key + "_EXPIRES"); v != "" {
+			expires, err := parseExpiry(v)
+			if err != nil {
+				errs = append(errs, fmt.Errorf("%s_EXPIRES: %w", key, err))
+				continue
+			}
+			tok.Expires = expires
+		}
+		if reservedPaths[secret] {
+			errs = append(errs, fmt.Errorf("%s: %q is reserved; choose another token", key, secret))
+			continue
+		}
+		loaded = append(loaded, tok)
+	}
+
+	sort.Slice(loaded, func(i, j int) bool { return loaded[i].Name < loaded[j].Name })
+	for i := 1; i < len(loaded); i++ {
+		if loaded[i].Secret == loaded[i-1].Secret {
+			errs = append(errs, fmt.Errorf("tokens %s and %s have the same secret", loaded[i-1].Name, loaded[i].Name))
+		}
+	}
+	if len(loaded) == 0 && len(errs) == 0 {
+		errs = append(errs, fmt.Errorf("TOKEN is required; set TOKEN (or TOKEN_{NAME}) in environment for URL prefix security"))
+	}
+	return loaded, errs
+}
+
+func isTokenAttribute(name string) bool {
+	for _, attr := range []string{"TARGETS", "EXPIRES"} {
+		if name == attr || strings.HasSuffix(name, "_"+attr) {
+			return true
+		}
+	}
+	return false
+}
+
+// tokenTargetPrefixes returns the prefixes of targets named TOKEN_{NAME},
+// i.e. those configured with a TOKEN_{NAME}_URL variable.
+func tokenTargetPrefixes() []string {
+	var prefixes []string
+	for _, env := range os.Environ() {
+		key, value, _ := strings.Cut(env, "=")
+		if value == "" || !strings.HasPrefix(key, "TOKEN_") || !strings.HasSuffix(key, "_URL") || globalURLVars[key] {
+			continue
+		}
+		if prefix := strings.TrimSuffix(key, "_URL"); prefix != "TOKEN" {
+			prefixes = append(prefixes, prefix)
+		}
+	}
+	return prefixes
+}
+
+// isTargetSetting reports whether key is a setting of one of the targets.
+func isTargetSetting(key string, prefixes []string) bool {
+	for _, prefix := range prefixes {
+		if key == prefix || strings.HasPrefix(key, prefix+"_") {
+			return true
+		}
+	}
+	return false
+}
+
+// parseExpiry accepts an RFC 3339 time or a date, meaning the end of that
+// day in the local time zone.
+func parseExpiry(s string) (time.Time, error) {
+	if t, err := time.Parse(time.RFC3339, s); err == nil {
+		return t, nil
+	}
+	day, err := time.ParseInLocation("2006-01-02", s, time.Local)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid time %q, use RFC 3339 or YYYY-MM-DD", s)
+	}
+	return day.AddDate(0, 0, 1), nil
+}
+
+// Ways a webhook request can carry its token.
+const (
+	authPath   = "path"   // /{TOKEN}/{identifier}
+	authBearer = "bearer" // Authorization: Bearer {TOKEN}
+	authHeader = "header" // {AUTH_HEADER}: {TOKEN}
+	authBasic  = "basic"  // HTTP Basic auth with the token as password
+)
+
+var authMethods = map[string]bool{authPath: true, authBearer: true, authHeader: true, authBasic: true}
+
+// defaultAuthHeader is the header read by "header" auth unless configured.
+const defaultAuthHeader = "X-Webhook-Token"
+
+// errNoCredentials means a header-authenticated target got no valid token.
+var errNoCredentials = errors.New("missing or unknown token")
+
+// accepts reports whether the target takes its token in the given way.
+func (t *target) accepts(method string) bool {
+	if len(t.Config.Auth) == 0 {
+		return method == authPath
+	}
+	for _, m := range t.Config.Auth {
+		if m == method {
+			return true
+		}
+	}
+	return false
+}
+
+// headerAuth reports whether the target takes its token outside the path.
+func (t *target) headerAuth() bool {
+	return t.accepts(authBearer) || t.accepts(authHeader) || t.accepts(authBasic)
+}
+
+// route describes the URLs a target answers on, without secrets.
+func (t *target) route() string {
+	var routes []string
+	if t.accepts(authPath) {
+		routes = append(routes, "/{TOKEN}"+t.Path)
+	}
+	if t.headerAuth() {
+		routes = append(routes, t.Path+" (token in header)")
+	}
+	return strings.Join(routes, ", ")
+}
+
+// headerSecret returns the token a request carries in the ways t accepts.
+func headerSecret(r *http.Request, t *target) string {
+	if t.accepts(authBearer) {
+		if secret, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer "); ok {
+			return secret
+		}
+	}
+	if t.accepts(authHeader) {
+		name := t.Config.AuthHeader
+		if name == "" {
+			name = defaultAuthHeader
+		}
+		if secret := r.Header.Get(name); secret != "" {
+			return secret
+		}
+	}
+	if t.accepts(authBasic) {
+		if _, secret, ok := r.BasicAuth(); ok {
+			return secret
+		}
+	}
+	return ""
+}
+
+// routeWebhook resolves a request to a target, either by the token prefix
+// /{secret}/{identifier} or, for targets that accept it, by /{identifier}
+// with the token in a header. Both forms take an optional /preview suffix.
+// The target is nil when the path is not a known webhook; an error means
+// the request is for a target but the token is missing or not valid for it.
+func routeWebhook(reg *registry, r *http.Request) (t *target, preview bool, err error) {
+	path := strings.TrimPrefix(r.URL.Path, "/")
+
+	if secret, rest, ok := strings.Cut(path, "/"); ok {
+		if tok := findToken(secret); tok != nil {
+			identifier, preview := strings.CutSuffix(rest, previewSuffix)
+			if t = reg.get(identifier); t != nil {
+				if !t.accepts(authPath) {
+					return t, preview, fmt.Errorf("target %s does not accept the token in the path", t.Identifier)
+				}
+				return t, preview, tok.allows(t.Identifier, time.Now())
+			}
+		}
+	}
+
+	identifier, preview := strings.CutSuffix(path, previewSuffix)
+	if t = reg.get(identifier); t == nil || !t.headerAuth() {
+		return nil, false, nil
+	}
+	tok := findToken(headerSecret(r, t))
+	if tok == nil {
+		return t, preview, errNoCredentials
+	}
+	return t, preview, tok.allows(t.Identifier, time.Now())
+}
+
+// logToken describes a token at startup without revealing its secret, and
+// warns about expired tokens and scopes naming unknown targets.
+func logToken(tok apiToken, reg *registry) {
+	scope := "all targets"
+	if len(tok.Targets) > 0 {
+		scope = strings.Join(tok.Targets, ", ")
+	}
+	expiry := "never expires"
+	if !tok.Expires.IsZero() {
+		expiry = "expires " + tok.Expires.Format(time.RFC3339)
+	}
+	log.Printf("token %s enabled for %s (%s)", tok.Name, scope, expiry)
+
+	if !tok.Expires.IsZero() && time.Now().After(tok.Expires) {
+		log.Printf("warning: token %s has expired; remove it from the configuration", tok.Name)
+	}
+	for _, id := range tok.Targets {
+		if reg.get(id) == nil {
+			log.Printf("warning: token %s is scoped to unknown target %s", tok.Name, id)
+		}
+	}
+}
@@ -0,0 +1,243 @@
+package main
+
+import (
+	"errors"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestTokenAllows(t *testing.T) {
+	now := time.Date(2026, 10, 19, 12, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name   string
+		tok    apiToken
+		target string
+		ok     bool
+	}{
+		{"unscoped", apiToken{Name: "default"}, "zulip", true},
+		{"in scope", apiToken{Name: "ops", Targets: []string{"zulip", "gotify"}}, "gotify", true},
+		{"out of scope", apiToken{Name: "ops", Targets: []string{"zulip"}}, "gotify", false},
+		{"scope is exact", apiToken{Name: "ops", Targets: []string{"zulip"}}, "zulip-eng", false},
+		{"not expired", apiToken{Name: "new", Expires: now.Add(time.Minute)}, "zulip", true},
+		{"expired", apiToken{Name: "old", Expires: now.Add(-time.Minute)}, "zulip", false},
+		{"expired in scope", apiToken{Name: "old", Targets: []string{"zulip"}, Expires: now.Add(-time.Minute)}, "zulip", false},
+	}
+
+	for _, tt := range tests {
+		err := tt.tok.allows(tt.target, now)
+		if (err == nil) != tt.ok {
+			t.Errorf("%s: allows(%q) = %v, want ok=%v", tt.name, tt.target, err, tt.ok)
+		}
+	}
+}
+
+func TestLoadTokens(t *testing.T) {
+	clearTokenEnv(t)
+	t.Setenv("TOKEN", "s3cret")
+	t.Setenv("TOKEN_OPS", "opsecret")
+	t.Setenv("TOKEN_OPS_TARGETS", "zulip, gotify")
+	t.Setenv("TOKEN_OLD", "oldsecret")
+	t.Setenv("TOKEN_OLD_EXPIRES", "2026-10-19T12:00:00Z")
+	t.Setenv("TOKEN_TARGETS", "zulip")
+	t.Setenv("TOKEN_WEBHOOK_URL", "https://example.com") // A target, not a token
+
+	loaded, errs := loadTokens()
+	if len(errs) > 0 {
+		t.Fatalf("loadTokens errors: %v", errs)
+	}
+
+	byName := make(map[string]apiToken)
+	for _, tok := range loaded {
+		byName[tok.Name] = tok
+	}
+	if len(byName) != 3 {
+		t.Fatalf("loaded tokens %v, want default, ops and old", loaded)
+	}
+	if tok := byName["default"]; tok.Secret != "s3cret" || strings.Join(tok.Targets, ",") != "zulip" {
+		t.Errorf("default token = %+v", tok)
+	}
+	if tok := byName["ops"]; tok.Secret != "opsecret" || strings.Join(tok.Targets, ",") != "zulip,gotify" {
+		t.Errorf("ops token = %+v", tok)
+	}
+	if tok := byName["old"]; !tok.Expires.Equal(time.Date(2026, 10, 19, 12, 0, 0, 0, time.UTC)) {
+		t.Errorf("old token expires %s", tok.Expires)
+	}
+}
+
+func TestLoadTokensSkipsTargetSettings(t *testing.T) {
+	clearTokenEnv(t)
+	t.Setenv("TOKEN", "s3cret")
+	t.Setenv("TOKEN_OPS", "opsecret")
+	t.Setenv("TOKEN_WEBHOOK_URL", "https://example.com")
+	t.Setenv("TOKEN_WEBHOOK_ALLOW_HTTP", "true")
+	t.Setenv("TOKEN_WEBHOOK_AUTH", "bearer")
+	t.Setenv("TOKEN_WEBHOOK", "ambiguous")
+	t.Setenv("TOKEN_URL", "https://example.com")
+
+	loaded, errs := loadTokens()
+	if len(errs) > 0 {
+		t.Fatalf("loadTokens errors: %v", errs)
+	}
+	var names []string
+	for _, tok := range loaded {
+		names = append(names, tok.Name)
+		if tok.Secret == "true" || tok.Secret == "bearer" {
+			t.Errorf("target setting loaded as token %s", tok.Name)
+		}
+	}
+	if got := strings.Join(names, ","); got != "default,ops" {
+		t.Errorf("loaded tokens %s, want default,ops", got)
+	}
+}
+
+func TestLoadTokensErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		env  map[string]string
+	}{
+		{"missing", map[string]string{}},
+		{"bad expiry", map[string]string{"TOKEN": "a", "TOKEN_EXPIRES": "tomorrow"}},
+		{"reserved", map[string]string{"TOKEN": "admin"}},
+		{"same secret", map[string]string{"TOKEN": "a", "TOKEN_OPS": "a"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			clearTokenEnv(t)
+			for k, v := range tt.env {
+				t.Setenv(k, v)
+			}
+			if _, errs := loadTokens(); len(errs) == 0 {
+				t.Error("loadTokens returned no errors")
+			}
+		})
+	}
+}
+
+func TestParseExpiry(t *testing.T) {
+	got, err := parseExpiry("2026-10-19T08:30:00+03:00")
+	if err != nil || !got.Equal(time.Date(2026, 10, 19, 5, 30, 0, 0, time.UTC)) {
+		t.Errorf("parseExpiry(RFC 3339) = %s, %v", got, err)
+	}
+
+	// A date is valid through the end of that day.
+	got, err = parseExpiry("2026-10-19")
+	if err != nil || !got.Equal(time.Date(2026, 10, 20, 0, 0, 0, 0, time.Local)) {
+		t.Errorf("parseExpiry(date) = %s, %v", got, err)
+	}
+
+	if _, err := parseExpiry("19.10.2026"); err == nil {
+		t.Error("parseExpiry accepted an invalid date")
+	}
+}
+
+func TestRouteWebhook(t *testing.T) {
+	saved := tokens
+	t.Cleanup(func() { tokens = saved })
+	tokens = []apiToken{
+		{Name: "default", Secret: "s3cret"},
+		{Name: "ops", Secret: "opsecret", Targets: []string{"zulip"}},
+		{Name: "old", Secret: "oldsecret", Expires: time.Now().Add(-time.Hour)},
+	}
+
+	reg := newRegistry()
+	for _, tg := range []*target{
+		{Identifier: "zulip", Path: "/zulip"},
+		{Identifier: "gc", Path: "/gc", Config: targetConfig{Auth: []string{authBearer, authHeader}}},
+		{Identifier: "both", Path: "/both", Config: targetConfig{Auth: []string{authPath, authBasic}, AuthHeader: "X-Unused"}},
+		{Identifier: "custom", Path: "/custom", Config: targetConfig{Auth: []string{authHeader}, AuthHeader: "X-Fizzy-Secret"}},
+	} {
+		reg.targets[tg.Identifier] = tg
+	}
+
+	tests := []struct {
+		name    string
+		path    string
+		headers map[string]string
+		basic   string // Basic auth password
+		target  string // Expected target; empty for no match
+		preview bool
+		ok      bool
+	}{
+		// Token in the path
+		{name: "path token", path: "/s3cret/zulip", target: "zulip", ok: true},
+		{name: "path preview", path: "/s3cret/zulip/preview", target: "zulip", preview: true, ok: true},
+		{name: "scoped token", path: "/opsecret/zulip", target: "zulip", ok: true},
+		{name: "token out of scope", path: "/opsecret/both", target: "both"},
+		{name: "expired token", path: "/oldsecret/zulip", target: "zulip"},
+		{name: "unknown token", path: "/wrong/zulip"},
+		{name: "unknown target", path: "/s3cret/nope"},
+		{name: "no token", path: "/zulip"},
+		{name: "path token on header target", path: "/s3cret/gc", target: "gc"},
+
+		// Token in a header
+		{name: "bearer", path: "/gc", headers: map[string]string{"Authorization": "Bearer s3cret"}, target: "gc", ok: true},
+		{name: "bearer preview", path: "/gc/preview", headers: map[string]string{"Authorization": "Bearer s3cret"}, target: "gc", preview: true, ok: true},
+		{name: "default header", path: "/gc", headers: map[string]string{"X-Webhook-Token": "s3cret"}, target: "gc", ok: true},
+		{name: "custom header", path: "/custom", headers: map[string]string{"X-Fizzy-Secret": "s3cret"}, target: "custom", ok: true},
+		{name: "default header on custom target", path: "/custom", headers: map[string]string{"X-Webhook-Token": "s3cret"}, target: "custom"},
+		{name: "missing header", path: "/gc", target: "gc"},
+		{name: "wrong bearer", path: "/gc", headers: map[string]string{"Authorization": "Bearer nope"}, target: "gc"},
+		{name: "bearer out of scope", path: "/gc", headers: map[string]string{"Authorization": "Bearer opsecret"}, target: "gc"},
+		{name: "expired bearer", path: "/gc", headers: map[string]string{"Authorization": "Bearer oldsecret"}, target: "gc"},
+		{name: "basic auth", path: "/both", basic: "s3cret", target: "both", ok: true},
+		{name: "basic auth not accepted", path: "/gc", basic: "s3cret", target: "gc"},
+		{name: "header on path-only target", path: "/zulip", headers: map[string]string{"Authorization": "Bearer s3cret"}},
+	}
+
+	for _, tt := range tests {
+		r := httptest.NewRequest("POST", tt.path, nil)
+		for k, v := range tt.headers {
+			r.Header.Set(k, v)
+		}
+		if tt.basic != "" {
+			r.SetBasicAuth("fizzy", tt.basic)
+		}
+
+		got, preview, err := routeWebhook(reg, r)
+		gotID := ""
+		if got != nil {
+			gotID = got.Identifier
+		}
+		if gotID != tt.target {
+			t.Errorf("%s: target = %q, want %q", tt.name, gotID, tt.target)
+			continue
+		}
+		if preview != tt.preview {
+			t.Errorf("%s: preview = %v, want %v", tt.name, preview, tt.preview)
+		}
+		if tt.target != "" && (err == nil) != tt.ok {
+			t.Errorf("%s: err = %v, want ok=%v", tt.name, err, tt.ok)
+		}
+		if tt.target == "" && err != nil {
+			t.Errorf("%s: err = %v for an unknown route", tt.name, err)
+		}
+	}
+}
+
+func TestRouteWebhookMissingCredentials(t *testing.T) {
+	saved := tokens
+	t.Cleanup(func() { tokens = saved })
+	tokens = []apiToken{{Name: "default", Secret: "s3cret"}}
+
+	reg := newRegistry()
+	reg.targets["gc"] = &target{Identifier: "gc", Path: "/gc", Config: targetConfig{Auth: []string{authBearer}}}
+
+	_, _, err := routeWebhook(reg, httptest.NewRequest("POST", "/gc", nil))
+	if !errors.Is(err, errNoCredentials) {
+		t.Errorf("err = %v, want errNoCredentials", err)
+	}
+}
+
+// clearTokenEnv hides TOKEN variables of the environment running the tests.
+func clearTokenEnv(t *testing.T) {
+	t.Helper()
+	for _, env := range os.Environ() {
+		if key, _, _ := strings.Cut(env, "="); key == "TOKEN" || strings.HasPrefix(key, "TOKEN_") {
+			t.Setenv(key, "")
+		}
+	}
+}
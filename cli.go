@@ -0,0 +1,133 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"text/tabwriter"
+	"time"
+)
+
+// --- Command Line ---
+
+// version is set at build time with -ldflags "-X main.version=v1.2.3".
+var version = "dev"
+
+const usage = `usage: fizzy-webhook-proxy [command] [arguments]
+
+Commands:
+  serve             run the proxy (default when no command is given)
+  validate-config   check the target configuration, exit non-zero on problems
+  list-targets      show the configured targets with secrets redacted
+  preview           render a Fizzy payload for a target type without sending it
+  test-target       send one test event per Fizzy action to a target
+  version           print the version
+
+Configuration is read from the environment and from .env in the working
+directory. Run "fizzy-webhook-proxy COMMAND -h" for the options of a command.
+`
+
+// runCommand dispatches to a subcommand and returns the exit code.
+func runCommand(args []string) int {
+	command := "serve"
+	if len(args) > 0 {
+		command, args = args[0], args[1:]
+	}
+
+	switch command {
+	case "serve":
+		if len(args) > 0 {
+			fmt.Fprint(os.Stderr, usage)
+			return 2
+		}
+		serve()
+		return 0
+	case "validate-config":
+		return runValidateConfig()
+	case "list-targets":
+		return runListTargets()
+	case "preview":
+		return runPreview(args)
+	case "test-target":
+		return runTestTarget(args)
+	case "version", "--version":
+		fmt.Printf("fizzy-webhook-proxy %s\n", version)
+		return 0
+	case "help", "-h", "--help":
+		fmt.Print(usage)
+		return 0
+	default:
+		fmt.Fprintf(os.Stderr, "unknown command %q\n\n%s", command, usage)
+		return 2
+	}
+}
+
+// loadConfiguredTargets reads the targets the server would start with.
+func loadConfiguredTargets() ([]*target, []error) {
+	debugMode = os.Getenv("DEBUG") == "true"
+
+	if err := loadUserDirectory(); err != nil {
+		return nil, []error{err}
+	}
+	store, err := openConfigStore(os.Getenv("CONFIG_STORE"))
+	if err != nil {
+		return nil, []error{fmt.Errorf("config store: %w", err)}
+	}
+	return loadTargets(store)
+}
+
+// runValidateConfig implements "fizzy-webhook-proxy validate-config".
+func runValidateConfig() int {
+	targets, errs := loadConfiguredTargets()
+	loaded, tokenErrs := loadTokens()
+	errs = append(tokenErrs, errs...)
+	if err := loadIngressSettings(); err != nil {
+		errs = append([]error{err}, errs...)
+	}
+
+	for _, tok := range loaded {
+		status := "ok"
+		if !tok.Expires.IsZero() && time.Now().After(tok.Expires) {
+			status = "expired"
+		}
+		fmt.Printf("%-6s token %s\n", status, tok.Name)
+	}
+
+	for _, t := range targets {
+		fmt.Printf("ok     %s (%s) at %s\n", t.Identifier, t.Type, t.route())
+	}
+	for _, err := range errs {
+		fmt.Printf("error  %v\n", err)
+	}
+
+	if len(errs) > 0 {
+		fmt.Printf("\n%d target(s) ok, %d problem(s)\n", len(targets), len(errs))
+		return 1
+	}
+	if len(targets) == 0 {
+		fmt.Println("no webhook targets configured; set <IDENTIFIER>_URL in environment")
+		return 1
+	}
+	fmt.Printf("\n%d target(s) ok\n", len(targets))
+	return 0
+}
+
+// runListTargets implements "fizzy-webhook-proxy list-targets". Tokens in
+// paths and credentials in webhook URLs are redacted.
+func runListTargets() int {
+	targets, errs := loadConfiguredTargets()
+	for _, err := range errs {
+		fmt.Fprintf(os.Stderr, "warning: skipping %v\n", err)
+	}
+
+	tw := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(tw, "IDENTIFIER\tTYPE\tPATH\tSTATUS\tURL")
+	for _, t := range targets {
+		status := "enabled"
+		if t.Disabled {
+			status = "disabled"
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\n", t.Identifier, t.Type, t.route(), status, redactURL(t.URL))
+	}
+	tw.Flush()
+	return 0
+}
@@ -0,0 +1,113 @@
+package main
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+)
+
+// --- Outbound HTTP Client ---
+
+const defaultHTTPTimeout = 10 * time.Second
+
+// clientConfig holds the outbound HTTP settings of a single target.
+// Values come from the target config and fall back to the global HTTP_*
+// defaults.
+type clientConfig struct {
+	Timeout    time.Duration
+	Proxy      string // Explicit proxy URL, "none" to disable, empty to use HTTP(S)_PROXY
+	CAFile     string // PEM bundle appended to the system roots
+	ClientCert string // PEM client certificate for mTLS
+	ClientKey  string // PEM client key for mTLS
+}
+
+// loadClientConfig resolves the outbound HTTP settings of a target.
+func loadClientConfig(tc targetConfig) (clientConfig, error) {
+	cfg := clientConfig{
+		Timeout:    defaultHTTPTimeout,
+		Proxy:      tc.Proxy,
+		CAFile:     tc.CAFile,
+		ClientCert: tc.ClientCert,
+		ClientKey:  tc.ClientKey,
+	}
+
+	timeout := tc.Timeout
+	if timeout == "" {
+		timeout = os.Getenv("HTTP_TIMEOUT")
+	}
+	if timeout != "" {
+		d, err := time.ParseDuration(timeout)
+		if err != nil || d <= 0 {
+			return cfg, fmt.Errorf("invalid timeout %q", timeout)
+		}
+		cfg.Timeout = d
+	}
+
+	if (cfg.ClientCert == "") != (cfg.ClientKey == "") {
+		return cfg, fmt.Errorf("client certificate and key must be set together")
+	}
+
+	return cfg, nil
+}
+
+// newHTTPClient builds a pooled client for a target. Each target gets its
+// own transport so TLS and proxy settings never leak between targets, while
+// keep-alive connections are reused across requests to the same upstream.
+func newHTTPClient(cfg clientConfig) (*http.Client, error) {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.MaxIdleConns = 100
+	transport.MaxIdleConnsPerHost = 10
+	transport.IdleConnTimeout = 90 * time.Second
+
+	switch strings.ToLower(cfg.Proxy) {
+	case "":
+		transport.Proxy = http.ProxyFromEnvironment
+	case "none", "direct":
+		transport.Proxy = nil
+	default:
+		proxyURL, err := url.Parse(cfg.Proxy)
+		if err != nil || proxyURL.Host == "" {
+			return nil, fmt.Errorf("invalid proxy URL %q", cfg.Proxy)
+		}
+		transport.Proxy = http.ProxyURL(proxyURL)
+	}
+
+	if cfg.CAFile != "" || cfg.ClientCert != "" {
+		tlsConfig := &tls.Config{MinVersion: tls.VersionTLS12}
+
+		if cfg.CAFile != "" {
+			pem, err := os.ReadFile(cfg.CAFile)
+			if err != nil {
+				return nil, fmt.Errorf("read CA bundle: %w", err)
+			}
+			pool, err := x509.SystemCertPool()
+			if err != nil || pool == nil {
+				pool = x509.NewCertPool()
+			}
+			if !pool.AppendCertsFromPEM(pem) {
+				return nil, fmt.Errorf("no certificates found in %s", cfg.CAFile)
+			}
+			tlsConfig.RootCAs = pool
+		}
+
+		if cfg.ClientCert != "" {
+			cert, err := tls.LoadX509KeyPair(cfg.ClientCert, cfg.ClientKey)
+			if err != nil {
+				return nil, fmt.Errorf("load client certificate: %w", err)
+			}
+			tlsConfig.Certificates = []tls.Certificate{cert}
+		}
+
+		transport.TLSClientConfig = tlsConfig
+	}
+
+	return &http.Client{
+		Timeout:   cfg.Timeout,
+		Transport: transport,
+	}, nil
+}
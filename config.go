@@ -0,0 +1,417 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// --- Target Configuration ---
+
+// targetConfig is the declarative configuration of a target. It is read from
+// {IDENTIFIER}_* environment variables or from the config store, and is what
+// the admin API exposes. Empty fields fall back to global defaults.
+type targetConfig struct {
+	Identifier       string            `json:"identifier"` // Path identifier, e.g. "status-page"
+	URL              string            `json:"url"`
+	Type             TargetType        `json:"type,omitempty"` // Detected from URL when empty
+	Disabled         bool              `json:"disabled,omitempty"`
+	Timeout          string            `json:"timeout,omitempty"`
+	Proxy            string            `json:"proxy,omitempty"`
+	CAFile           string            `json:"ca_file,omitempty"`
+	ClientCert       string            `json:"client_cert,omitempty"`
+	ClientKey        string            `json:"client_key,omitempty"`
+	RateLimit        string            `json:"rate_limit,omitempty"`
+	RateBurst        int               `json:"rate_burst,omitempty"`
+	AggregateWindow  string            `json:"aggregate_window,omitempty"`
+	SummarySchedule  string            `json:"summary_schedule,omitempty"`
+	SummaryOnly      bool              `json:"summary_only,omitempty"`
+	Timezone         string            `json:"timezone,omitempty"`
+	QuietHours       string            `json:"quiet_hours,omitempty"`
+	QuietDays        string            `json:"quiet_days,omitempty"`
+	UrgentActions    []string          `json:"urgent_actions,omitempty"`
+	AllowHTTP        bool              `json:"allow_http,omitempty"`        // Permit plain http:// URLs
+	Auth             []string          `json:"auth,omitempty"`              // Accepted token locations; default path
+	AuthHeader       string            `json:"auth_header,omitempty"`       // Header for "header" auth
+	AllowedIPs       []string          `json:"allowed_ips,omitempty"`       // Addresses or CIDR ranges; default ALLOWED_IPS
+	Template         string            `json:"template,omitempty"`          // Go template replacing the built-in message
+	TemplateFile     string            `json:"template_file,omitempty"`     // Same, read from a file
+	Locale           string            `json:"locale,omitempty"`            // Message language, e.g. "tr"; default LOCALE
+	VocabularyFile   string            `json:"vocabulary_file,omitempty"`   // JSON file with verbs, emojis and columns
+	Emojis           map[string]string `json:"emojis,omitempty"`            // Emoji per action, e.g. card_closed: 🔒
+	CompletedColumns []string          `json:"completed_columns,omitempty"` // Default COMPLETED_COLUMNS, then Done
+	PostponedColumns []string          `json:"postponed_columns,omitempty"` // Default POSTPONED_COLUMNS, then Postponed, Not Now
+	DirectMessages   []string          `json:"direct_messages,omitempty"`   // "assigned", "mentions"; default DIRECT_MESSAGES
+	CardStyle        string            `json:"card_style,omitempty"`        // Google Chat: "full" or "compact"; default CARD_STYLE
+	ThreadByCard     bool              `json:"thread_by_card,omitempty"`    // Google Chat: one thread per card; default THREAD_BY_CARD
+	StatusMessages   bool              `json:"status_messages,omitempty"`   // Zulip: edit one message per card; default STATUS_MESSAGES
+}
+
+// pathIdentifier converts an environment prefix to the identifier used in
+// URL paths. Example: STATUS_PAGE -> status-page
+func pathIdentifier(prefix string) string {
+	return strings.ToLower(strings.ReplaceAll(prefix, "_", "-"))
+}
+
+// targetConfigFromEnv reads the {prefix}_* variables of one target.
+func targetConfigFromEnv(prefix, webhookURL string) (targetConfig, error) {
+	env := func(suffix string) string {
+		return os.Getenv(prefix + "_" + suffix)
+	}
+
+	cfg := targetConfig{
+		Identifier:       pathIdentifier(prefix),
+		URL:              webhookURL,
+		Type:             TargetType(strings.ToLower(env("TYPE"))),
+		Disabled:         env("DISABLED") == "true",
+		Timeout:          env("TIMEOUT"),
+		Proxy:            env("PROXY"),
+		CAFile:           env("CA_FILE"),
+		ClientCert:       env("CLIENT_CERT"),
+		ClientKey:        env("CLIENT_KEY"),
+		RateLimit:        env("RATE_LIMIT"),
+		AggregateWindow:  env("AGGREGATE_WINDOW"),
+		SummarySchedule:  env("SUMMARY_SCHEDULE"),
+		SummaryOnly:      env("SUMMARY_ONLY") == "true",
+		Timezone:         env("TIMEZONE"),
+		QuietHours:       env("QUIET_HOURS"),
+		QuietDays:        env("QUIET_DAYS"),
+		UrgentActions:    splitList(env("URGENT_ACTIONS")),
+		AllowHTTP:        env("ALLOW_HTTP") == "true",
+		Auth:             splitList(strings.ToLower(env("AUTH"))),
+		AuthHeader:       env("AUTH_HEADER"),
+		AllowedIPs:       splitList(env("ALLOWED_IPS")),
+		Template:         env("TEMPLATE"),
+		TemplateFile:     env("TEMPLATE_FILE"),
+		Locale:           env("LOCALE"),
+		VocabularyFile:   env("VOCABULARY_FILE"),
+		CompletedColumns: splitList(env("COMPLETED_COLUMNS")),
+		PostponedColumns: splitList(env("POSTPONED_COLUMNS")),
+		DirectMessages:   splitList(strings.ToLower(env("DIRECT_MESSAGES"))),
+		CardStyle:        env("CARD_STYLE"),
+		ThreadByCard:     env("THREAD_BY_CARD") == "true",
+		StatusMessages:   env("STATUS_MESSAGES") == "true",
+	}
+
+	emojis, err := parseEmojis(env("EMOJIS"))
+	if err != nil {
+		return cfg, fmt.Errorf("%s_EMOJIS: %w", prefix, err)
+	}
+	cfg.Emojis = emojis
+
+	if v := env("RATE_BURST"); v != "" {
+		burst, err := strconv.Atoi(v)
+		if err != nil {
+			return cfg, fmt.Errorf("%s_RATE_BURST: invalid number %q", prefix, v)
+		}
+		cfg.RateBurst = burst
+	}
+
+	return cfg, nil
+}
+
+// buildTarget turns a config into a ready-to-use target with its HTTP client
+// and delivery helpers.
+func buildTarget(cfg targetConfig) (*target, error) {
+	if cfg.Identifier == "" {
+		return nil, fmt.Errorf("identifier is required")
+	}
+	if reservedPaths[cfg.Identifier] {
+		return nil, fmt.Errorf("identifier %q is reserved", cfg.Identifier)
+	}
+	if cfg.URL == "" {
+		return nil, fmt.Errorf("url is required")
+	}
+	if err := checkTargetURL(cfg.URL, cfg.AllowHTTP || os.Getenv("ALLOW_HTTP") == "true"); err != nil {
+		return nil, err
+	}
+
+	targetType := cfg.Type
+	if targetType == "" {
+		targetType = detectTargetType(cfg.URL)
+	}
+	if targetType == "" {
+		return nil, fmt.Errorf("cannot detect type from URL, set the type explicitly")
+	}
+	if !knownTargetType(targetType) {
+		return nil, fmt.Errorf("unknown type %q (expected zulip, google-chat or gotify)", targetType)
+	}
+	for _, method := range cfg.Auth {
+		if !authMethods[method] {
+			return nil, fmt.Errorf("unknown auth method %q (expected path, bearer, header or basic)", method)
+		}
+	}
+
+	t := &target{
+		Name:       cfg.Identifier,
+		Path:       "/" + cfg.Identifier,
+		URL:        cfg.URL,
+		Type:       targetType,
+		Identifier: cfg.Identifier,
+		Disabled:   cfg.Disabled,
+		Config:     cfg,
+	}
+
+	allowed := cfg.AllowedIPs
+	if len(allowed) == 0 {
+		allowed = splitList(os.Getenv("ALLOWED_IPS"))
+	}
+	var err error
+	if t.AllowedIPs, err = parsePrefixes(allowed); err != nil {
+		return nil, fmt.Errorf("allowed ips: %w", err)
+	}
+
+	if t.Catalog, err = loadCatalog(cfg); err != nil {
+		return nil, err
+	}
+	t.Catalog = t.Catalog.withMentions(users.mentions(targetType))
+
+	if t.DirectMessages, err = loadDirectMessages(cfg); err != nil {
+		return nil, err
+	}
+
+	if t.CompactCards, err = loadCardStyle(cfg); err != nil {
+		return nil, err
+	}
+
+	if cfg.ThreadByCard && targetType != TargetGoogleChat {
+		return nil, fmt.Errorf("thread by card is only supported for google-chat targets")
+	}
+	t.ThreadByCard = targetType == TargetGoogleChat && (cfg.ThreadByCard || os.Getenv("THREAD_BY_CARD") == "true")
+
+	if t.Status, err = loadStatusMessages(cfg, targetType); err != nil {
+		return nil, err
+	}
+
+	if t.Template, err = loadTemplate(cfg, t.Catalog); err != nil {
+		return nil, err
+	}
+
+	clientCfg, err := loadClientConfig(cfg)
+	if err != nil {
+		return nil, err
+	}
+	if t.Client, err = newHTTPClient(clientCfg); err != nil {
+		return nil, err
+	}
+
+	if t.Limiter, err = loadRateLimiter(cfg, func(events []event) {
+		deliverBatch(*t, events)
+	}); err != nil {
+		return nil, fmt.Errorf("rate limit: %w", err)
+	}
+
+	if t.Aggregator, err = loadAggregator(cfg, func(ev event) {
+		dispatch(*t, ev)
+	}); err != nil {
+		return nil, fmt.Errorf("aggregation: %w", err)
+	}
+
+	loc, err := loadLocation(cfg)
+	if err != nil {
+		return nil, fmt.Errorf("time zone: %w", err)
+	}
+
+	if t.Summary, err = loadSummarizer(cfg, loc, t.Catalog); err != nil {
+		return nil, fmt.Errorf("summary schedule: %w", err)
+	}
+
+	if t.Quiet, err = loadQuietHours(cfg, loc, func(events []event) {
+		if t.Limiter != nil {
+			t.Limiter.Wait()
+		}
+		deliverBatch(*t, events)
+	}); err != nil {
+		return nil, fmt.Errorf("quiet hours: %w", err)
+	}
+
+	return t, nil
+}
+
+// reservedPaths are first path segments the server uses itself; neither an
+// identifier nor a token may take them.
+var reservedPaths = map[string]bool{
+	"admin":     true,
+	"dashboard": true,
+	"preview":   true,
+}
+
+// checkTargetURL rejects URLs that cannot be forwarded to. Plain http is
+// refused unless allowHTTP is set, as webhook URLs usually carry secrets.
+func checkTargetURL(raw string, allowHTTP bool) error {
+	u, err := url.Parse(raw)
+	if err != nil {
+		return fmt.Errorf("invalid url: %v", err)
+	}
+	if (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return fmt.Errorf("invalid url %q: must be an absolute http(s) URL", redactURL(raw))
+	}
+	if u.Scheme == "http" && !allowHTTP {
+		return fmt.Errorf("url %q uses plain http; use https or set {IDENTIFIER}_ALLOW_HTTP=true (allow_http)", redactURL(raw))
+	}
+	return nil
+}
+
+// formatConfigErrors renders configuration problems as an indented list.
+func formatConfigErrors(errs []error) string {
+	var b strings.Builder
+	for _, err := range errs {
+		fmt.Fprintf(&b, "\n  - %v", err)
+	}
+	return b.String()
+}
+
+// redactURL hides credentials in a webhook URL: the password of the user
+// info and every query parameter value (API keys, tokens).
+func redactURL(raw string) string {
+	u, err := url.Parse(raw)
+	if err != nil {
+		return "(unparseable)"
+	}
+	if u.User != nil {
+		if _, ok := u.User.Password(); ok {
+			u.User = url.UserPassword(u.User.Username(), "REDACTED")
+		}
+	}
+	q := u.Query()
+	for key := range q {
+		q.Set(key, "REDACTED")
+	}
+	u.RawQuery = q.Encode()
+	return u.String()
+}
+
+// splitList splits a comma separated value, dropping empty items.
+func splitList(s string) []string {
+	var items []string
+	for _, item := range strings.Split(s, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+// --- Config Store ---
+
+// configStore persists targets managed through the admin API in a JSON file
+// (CONFIG_STORE). Entries override environment targets with the same
+// identifier; deleted environment targets are kept as tombstones so they do
+// not come back on restart.
+type configStore struct {
+	mu      sync.Mutex
+	path    string
+	entries map[string]storeEntry
+}
+
+type storeEntry struct {
+	targetConfig
+	Deleted bool `json:"deleted,omitempty"`
+}
+
+type storeFile struct {
+	Targets []storeEntry `json:"targets"`
+}
+
+// openConfigStore loads the store at path. A missing file is an empty store;
+// an empty path gives an in-memory store whose changes are lost on restart.
+func openConfigStore(path string) (*configStore, error) {
+	s := &configStore{path: path, entries: make(map[string]storeEntry)}
+	if path == "" {
+		return s, nil
+	}
+
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return s, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var file storeFile
+	if err := json.Unmarshal(data, &file); err != nil {
+		return nil, fmt.Errorf("parse %s: %w", path, err)
+	}
+	for _, e := range file.Targets {
+		s.entries[e.Identifier] = e
+	}
+	return s, nil
+}
+
+// apply overlays the stored entries on the configs read from the environment.
+func (s *configStore) apply(configs []targetConfig) []targetConfig {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var result []targetConfig
+	seen := make(map[string]bool)
+	for _, cfg := range configs {
+		seen[cfg.Identifier] = true
+		if e, ok := s.entries[cfg.Identifier]; ok {
+			if !e.Deleted {
+				result = append(result, e.targetConfig)
+			}
+			continue
+		}
+		result = append(result, cfg)
+	}
+	for id, e := range s.entries {
+		if !seen[id] && !e.Deleted {
+			result = append(result, e.targetConfig)
+		}
+	}
+
+	sort.Slice(result, func(i, j int) bool { return result[i].Identifier < result[j].Identifier })
+	return result
+}
+
+// put records cfg and writes the store to disk.
+func (s *configStore) put(cfg targetConfig) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.entries[cfg.Identifier] = storeEntry{targetConfig: cfg}
+	return s.save()
+}
+
+// remove records a deletion and writes the store to disk.
+func (s *configStore) remove(identifier string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.entries[identifier] = storeEntry{targetConfig: targetConfig{Identifier: identifier}, Deleted: true}
+	return s.save()
+}
+
+// save writes the store atomically. Callers hold s.mu.
+func (s *configStore) save() error {
+	if s.path == "" {
+		return nil
+	}
+
+	file := storeFile{Targets: make([]storeEntry, 0, len(s.entries))}
+	for _, e := range s.entries {
+		file.Targets = append(file.Targets, e)
+	}
+	sort.Slice(file.Targets, func(i, j int) bool { return file.Targets[i].Identifier < file.Targets[j].Identifier })
+
+	data, err := json.MarshalIndent(file, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(s.path), 0o700); err != nil {
+		return err
+	}
+	tmp := s.path + ".tmp"
+	// Target URLs carry upstream secrets, keep the file private.
+	if err := os.WriteFile(tmp, data, 0o600); err != nil {
+		return err
+	}
+	return os.Rename(tmp, s.path)
+}
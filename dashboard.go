@@ -0,0 +1,250 @@
+package main
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/subtle"
+	_ "embed"
+	"encoding/hex"
+	"fmt"
+	"html/template"
+	"log"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// --- Delivery Log ---
+
+// defaultDeliveryHistory is how many deliveries are kept per target.
+const defaultDeliveryHistory = 50
+
+// delivery is the outcome of one message sent to a target.
+type delivery struct {
+	ID      uint64
+	Target  string
+	Time    time.Time
+	Action  string // Fizzy action, "digest" or "summary"
+	Subject string
+	Events  []event // Incoming events the message was built from
+	Body    []byte  // Translated message as sent upstream
+	Status  int     // Upstream HTTP status; 0 if the request failed
+	Error   string
+	Latency time.Duration
+}
+
+// OK reports whether the upstream accepted the message.
+func (d delivery) OK() bool {
+	return d.Error == "" && d.Status >= 200 && d.Status < 300
+}
+
+// deliveryLog keeps the most recent deliveries per target in memory.
+type deliveryLog struct {
+	mu       sync.Mutex
+	limit    int
+	nextID   uint64
+	byTarget map[string][]delivery
+}
+
+var deliveries = newDeliveryLog(defaultDeliveryHistory)
+
+func newDeliveryLog(limit int) *deliveryLog {
+	return &deliveryLog{limit: limit, byTarget: make(map[string][]delivery)}
+}
+
+func (l *deliveryLog) record(t target, events []event, body []byte, resp *upstreamResponse, err error, latency time.Duration) {
+	d := delivery{
+		Target:  t.Identifier,
+		Time:    time.Now(),
+		Events:  events,
+		Body:    body,
+		Latency: latency,
+	}
+	switch {
+	case len(events) == 0:
+		d.Action = "summary"
+	case len(events) == 1 && len(events[0].Merged) > 1:
+		d.Action = fmt.Sprintf("merged (%d events)", len(events[0].Merged))
+		d.Subject = mergedSubject(defaultCatalog, events[0].Merged)
+	case len(events) == 1:
+		d.Action = events[0].Payload.Action
+		d.Subject = resolveSubject(defaultCatalog, events[0].Payload)
+	default:
+		d.Action = fmt.Sprintf("digest (%d events)", len(events))
+	}
+	if resp != nil {
+		d.Status = resp.StatusCode
+	}
+	if err != nil {
+		d.Error = err.Error()
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.nextID++
+	d.ID = l.nextID
+	list := append(l.byTarget[t.Identifier], d)
+	if len(list) > l.limit {
+		list = list[len(list)-l.limit:]
+	}
+	l.byTarget[t.Identifier] = list
+}
+
+// recent returns the deliveries of a target, newest first.
+func (l *deliveryLog) recent(identifier string) []delivery {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	list := l.byTarget[identifier]
+	out := make([]delivery, len(list))
+	for i, d := range list {
+		out[len(list)-1-i] = d
+	}
+	return out
+}
+
+func (l *deliveryLog) find(id uint64) (delivery, bool) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	for _, list := range l.byTarget {
+		for _, d := range list {
+			if d.ID == id {
+				return d, true
+			}
+		}
+	}
+	return delivery{}, false
+}
+
+// --- Dashboard ---
+
+//go:embed dashboard.html
+var dashboardHTML string
+
+var dashboardTemplate = template.Must(template.New("dashboard").Funcs(template.FuncMap{
+	"ms": func(d time.Duration) string {
+		return strconv.FormatInt(d.Milliseconds(), 10) + " ms"
+	},
+	"preview": func(b []byte) string {
+		const max = 4000
+		if len(b) > max {
+			return string(b[:max]) + "…"
+		}
+		return string(b)
+	},
+}).Parse(dashboardHTML))
+
+// dashboard serves the HTML overview of recent deliveries at "/" and the
+// replay action. It uses the admin token: browsers log in with HTTP Basic
+// auth using the token as password (the user name is ignored).
+type dashboard struct {
+	token     string
+	registry  *registry
+	csrfToken string // Guards the replay form against cross-site posts
+}
+
+type dashboardTarget struct {
+	Target     *target
+	Deliveries []delivery
+}
+
+func newDashboard(token string, reg *registry) *dashboard {
+	buf := make([]byte, 16)
+	if _, err := rand.Read(buf); err != nil {
+		log.Fatalf("dashboard: %v", err)
+	}
+	return &dashboard{token: token, registry: reg, csrfToken: hex.EncodeToString(buf)}
+}
+
+func (d *dashboard) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if !adminAuthorized(r, d.token) {
+		w.Header().Set("WWW-Authenticate", `Basic realm="fizzy-webhook-proxy"`)
+		http.Error(w, "unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	switch r.URL.Path {
+	case "/":
+		d.serveIndex(w, r)
+	case "/dashboard/replay":
+		d.serveReplay(w, r)
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+func (d *dashboard) serveIndex(w http.ResponseWriter, r *http.Request) {
+	var data struct {
+		Targets   []dashboardTarget
+		CSRFToken string
+		Now       time.Time
+	}
+	data.CSRFToken = d.csrfToken
+	data.Now = time.Now()
+	for _, t := range d.registry.list() {
+		data.Targets = append(data.Targets, dashboardTarget{Target: t, Deliveries: deliveries.recent(t.Identifier)})
+	}
+
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	if err := dashboardTemplate.Execute(w, data); err != nil {
+		log.Printf("dashboard render error: %v", err)
+	}
+}
+
+func (d *dashboard) serveReplay(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	if subtle.ConstantTimeCompare([]byte(r.FormValue("csrf")), []byte(d.csrfToken)) != 1 {
+		http.Error(w, "invalid form token", http.StatusForbidden)
+		return
+	}
+
+	id, err := strconv.ParseUint(r.FormValue("id"), 10, 64)
+	if err != nil {
+		http.Error(w, "invalid delivery id", http.StatusBadRequest)
+		return
+	}
+	if err := replayDelivery(r.Context(), d.registry, id); err != nil {
+		log.Printf("replay of delivery %d failed: %v", id, err)
+		http.Error(w, fmt.Sprintf("replay failed: %v", err), http.StatusBadGateway)
+		return
+	}
+
+	http.Redirect(w, r, "/", http.StatusSeeOther)
+}
+
+// replayDelivery sends a recorded delivery again. Events are re-translated
+// with the target's current configuration; summaries are re-sent as is.
+func replayDelivery(ctx context.Context, reg *registry, id uint64) error {
+	d, ok := deliveries.find(id)
+	if !ok {
+		return fmt.Errorf("delivery %d not found", id)
+	}
+	t := reg.get(d.Target)
+	if t == nil {
+		return fmt.Errorf("target %q no longer exists", d.Target)
+	}
+
+	body := d.Body
+	if len(d.Events) > 0 {
+		var err error
+		if body, err = translateEvents(*t, d.Events); err != nil {
+			return err
+		}
+	}
+
+	log.Printf("[INFO] Replaying delivery %d to %s", id, t.Name)
+	resp, err := deliver(ctx, *t, d.Events, body)
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("upstream answered %d", resp.StatusCode)
+	}
+	return nil
+}
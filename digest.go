@@ -0,0 +1,114 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"html"
+	"strings"
+	"time"
+)
+
+// --- Digest Translation ---
+
+// maxDigestEvents bounds how many events go into one digest message. Larger
+// flushes are split, as Google Chat rejects messages over 32 KB.
+const maxDigestEvents = 50
+
+// translateDigest folds several events into one message for the target, used
+// when queued events are flushed together.
+func translateDigest(t target, events []event) ([]byte, error) {
+	title := t.Catalog.text("digest_title", len(events))
+
+	switch t.Type {
+	case TargetZulip:
+		return json.Marshal(ZulipPayload{
+			Content: buildDigestMessage(t.Catalog, title, events),
+		})
+	case TargetGotify:
+		return json.Marshal(GotifyPayload{
+			Message:  buildDigestMessage(t.Catalog, title, events),
+			Title:    "Fizzy: " + title,
+			Priority: 5,
+			Extras: map[string]interface{}{
+				"client::display": map[string]string{
+					"contentType": "text/markdown",
+				},
+			},
+		})
+	case TargetGoogleChat:
+		// Digests are a single card, which cannot render mentions.
+		return translateDigestToGoogleChat(t.Catalog.withMentions(nil), title, events)
+	default:
+		raw := make([]json.RawMessage, 0, len(events))
+		for _, ev := range events {
+			raw = append(raw, ev.Raw)
+		}
+		return json.Marshal(raw)
+	}
+}
+
+// digestLine renders an event as a single markdown line with a link.
+func digestLine(cat *catalog, ev event) string {
+	if len(ev.Merged) > 1 {
+		_, emoji := prettyAction(cat, ev.Payload)
+		return fmt.Sprintf("%s [%s](%s): %s", emoji, mergedSubject(cat, ev.Merged), mergedURL(ev.Merged), mergedSummary(cat, ev.Merged))
+	}
+	f := ev.Payload
+	verb, emoji := prettyAction(cat, f)
+	return fmt.Sprintf("%s **%s** %s: [%s](%s)", emoji, actorName(cat, f), verb, resolveSubject(cat, f), resolveFizzyURL(f))
+}
+
+func buildDigestMessage(cat *catalog, title string, events []event) string {
+	var sb strings.Builder
+	sb.WriteString(fmt.Sprintf("### 📚 %s", title))
+	sb.WriteString("\n")
+	for _, ev := range events {
+		sb.WriteString("\n- ")
+		sb.WriteString(digestLine(cat, ev))
+	}
+	return sb.String()
+}
+
+func translateDigestToGoogleChat(cat *catalog, title string, events []event) ([]byte, error) {
+	var widgets []Widget
+	for _, ev := range events {
+		f := ev.Payload
+		if len(ev.Merged) > 1 {
+			_, emoji := prettyAction(cat, f)
+			widgets = append(widgets, Widget{
+				DecoratedText: &DecoratedText{
+					TopLabel: fmt.Sprintf("%s %s", emoji, mergedSummary(cat, ev.Merged)),
+					Text:     chatLink(mergedURL(ev.Merged), mergedSubject(cat, ev.Merged)),
+				},
+			})
+			continue
+		}
+		verb, emoji := prettyAction(cat, f)
+		widgets = append(widgets, Widget{
+			DecoratedText: &DecoratedText{
+				TopLabel: fmt.Sprintf("%s %s %s", emoji, actorName(cat, f), verb),
+				Text:     chatLink(resolveFizzyURL(f), resolveSubject(cat, f)),
+			},
+		})
+	}
+
+	card := CardV2{
+		CardID: fmt.Sprintf("fizzy-digest-%d", time.Now().UnixNano()),
+		Card: Card{
+			Header:   CardHeader{Title: title, Subtitle: "Fizzy"},
+			Sections: []CardSection{{Widgets: widgets}},
+		},
+	}
+
+	payload := GoogleChatPayload{
+		Text:    "📚 " + title,
+		CardsV2: []CardV2{card},
+	}
+	return json.Marshal(payload)
+}
+
+// chatLink renders a link for Google Chat card text, which is HTML, so card
+// titles and board names are escaped.
+func chatLink(url, text string) string {
+	return fmt.Sprintf(`<a href="%s">%s</a>`, html.EscapeString(url), html.EscapeString(text))
+}
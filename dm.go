@@ -0,0 +1,362 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"mime"
+	"net/http"
+	"net/smtp"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+	"unicode"
+	"unicode/utf8"
+)
+
+// --- Direct Messages ---
+
+// Kinds of direct messages a target can send, see {ID}_DIRECT_MESSAGES.
+const (
+	directAssigned = "assigned" // card_assigned goes to the assignee
+	directMentions = "mentions" // comment_created goes to users @mentioned in it
+)
+
+const defaultNtfyServer = "https://ntfy.sh"
+
+// directSentWindow is how long a direct message is remembered, so targets
+// receiving the same Fizzy event do not send it again. It covers the longest
+// quiet hours a message can be held for.
+const directSentWindow = 8 * 24 * time.Hour
+
+var (
+	directSent   = make(map[string]time.Time) // recipient|event -> time sent
+	directSentMu sync.Mutex
+)
+
+// directClient sends direct messages; they are not tied to a target's
+// outbound HTTP settings.
+var directClient = &http.Client{Timeout: defaultHTTPTimeout}
+
+// loadDirectMessages validates the direct message kinds of a target.
+func loadDirectMessages(tc targetConfig) (map[string]bool, error) {
+	kinds := tc.DirectMessages
+	if len(kinds) == 0 {
+		kinds = splitList(strings.ToLower(os.Getenv("DIRECT_MESSAGES")))
+	}
+	if len(kinds) == 0 {
+		return nil, nil
+	}
+	enabled := make(map[string]bool, len(kinds))
+	for _, kind := range kinds {
+		if kind != directAssigned && kind != directMentions {
+			return nil, fmt.Errorf("unknown direct message kind %q (expected assigned or mentions)", kind)
+		}
+		enabled[kind] = true
+	}
+	return enabled, nil
+}
+
+// checkDirectDestinations reports destinations that cannot be used because
+// the server settings they need are missing.
+func checkDirectDestinations(u directoryUser) error {
+	if u.ZulipPM != "" && (os.Getenv("ZULIP_SITE") == "" || os.Getenv("ZULIP_BOT_EMAIL") == "" || os.Getenv("ZULIP_BOT_API_KEY") == "") {
+		return fmt.Errorf("zulip_pm needs ZULIP_SITE, ZULIP_BOT_EMAIL and ZULIP_BOT_API_KEY")
+	}
+	if u.Gotify != "" && !strings.Contains(u.Gotify, "://") && os.Getenv("GOTIFY_SERVER") == "" {
+		return fmt.Errorf("gotify app token needs GOTIFY_SERVER")
+	}
+	if u.Email != "" && (os.Getenv("SMTP_ADDR") == "" || os.Getenv("SMTP_FROM") == "") {
+		return fmt.Errorf("email needs SMTP_ADDR and SMTP_FROM")
+	}
+	return nil
+}
+
+// directRecipients returns who gets a direct message about f from t. People
+// are never messaged about their own actions.
+func directRecipients(t target, f FizzyPayload) []string {
+	var names []string
+	add := func(name string) {
+		if name == "" || strings.EqualFold(name, f.Creator.Name) {
+			return
+		}
+		for _, n := range names {
+			if strings.EqualFold(n, name) {
+				return
+			}
+		}
+		if _, ok := users.lookup(name); ok {
+			names = append(names, name)
+		}
+	}
+
+	switch strings.ToLower(f.Action) {
+	case "card_assigned":
+		if t.DirectMessages[directAssigned] && f.Assignee != nil {
+			add(f.Assignee.Name)
+		}
+	case "comment_created":
+		if t.DirectMessages[directMentions] {
+			body := strings.ToLower(f.Eventable.Body.PlainText)
+			for name := range users {
+				if mentioned(body, name) {
+					add(name)
+				}
+			}
+		}
+	}
+	return names
+}
+
+// mentioned reports whether body @mentions name as a whole word, so "@al"
+// is not found in "@alice" and "@jane doe" not in "@jane doering".
+func mentioned(body, name string) bool {
+	needle := "@" + name
+	for i := 0; i < len(body); {
+		j := strings.Index(body[i:], needle)
+		if j < 0 {
+			return false
+		}
+		start, end := i+j, i+j+len(needle)
+		before, _ := utf8.DecodeLastRuneInString(body[:start])
+		after, _ := utf8.DecodeRuneInString(body[end:])
+		if (start == 0 || !isWordRune(before)) && (end == len(body) || !isWordRune(after)) {
+			return true
+		}
+		i = start + 1
+	}
+	return false
+}
+
+func isWordRune(r rune) bool {
+	return unicode.IsLetter(r) || unicode.IsDigit(r) || r == '_'
+}
+
+// claimDirect reports whether name has not been messaged about the Fizzy
+// event f yet, and records that they are now. Events are told apart by
+// their Fizzy ID, which is the same for every target receiving them.
+func claimDirect(name string, f FizzyPayload) bool {
+	id := f.ID
+	if id == "" && f.Eventable.ID != "" {
+		id = f.Action + "/" + f.Eventable.ID
+	}
+	if id == "" {
+		return true
+	}
+	key := strings.ToLower(name) + "|" + id
+
+	directSentMu.Lock()
+	defer directSentMu.Unlock()
+
+	now := time.Now()
+	for k, at := range directSent {
+		if now.Sub(at) > directSentWindow {
+			delete(directSent, k)
+		}
+	}
+	if _, ok := directSent[key]; ok {
+		return false
+	}
+	directSent[key] = now
+	return true
+}
+
+// sendDirectMessages notifies the recipients of ev in the background, in
+// addition to the target's channel message. During the target's quiet hours
+// the messages wait for the window to close, unless the action is urgent.
+// Each recipient gets one message per Fizzy event, however many targets
+// receive it. Failures are only logged.
+func sendDirectMessages(t target, ev event) {
+	names := directRecipients(t, ev.Payload)
+	if len(names) == 0 {
+		return
+	}
+
+	f := ev.Payload
+	cat := t.Catalog.withMentions(nil)
+	verb, _ := prettyAction(cat, f)
+	msg := directMessage{
+		Title:   strings.ReplaceAll(fmt.Sprintf("Fizzy: %s %s", actorName(cat, f), verb), "**", ""),
+		Text:    buildMessage(cat, f),
+		URL:     resolveFizzyURL(f),
+		Payload: f,
+		Catalog: cat,
+	}
+
+	send := func() {
+		for _, name := range names {
+			if !claimDirect(name, f) {
+				log.Printf("[INFO] Direct message already sent: Target=%s User=%s ID=%s", t.Name, name, ev.ID)
+				continue
+			}
+			u, _ := users.lookup(name)
+			for _, d := range u.destinations() {
+				ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+				err := d.send(ctx, msg)
+				cancel()
+				if err != nil {
+					log.Printf("[WARN] Direct message to %s via %s failed for event %s: %v", name, d.kind, ev.ID, err)
+					continue
+				}
+				log.Printf("[INFO] Direct message sent: Target=%s User=%s Via=%s ID=%s", t.Name, name, d.kind, ev.ID)
+			}
+		}
+	}
+
+	if t.Quiet != nil {
+		if delay := t.Quiet.Delay(ev); delay > 0 {
+			log.Printf("[INFO] Quiet hours, holding direct messages: Target=%s Action=%s ID=%s For=%s", t.Name, f.Action, ev.ID, delay.Round(time.Minute))
+			time.AfterFunc(delay, send)
+			return
+		}
+	}
+	go send()
+}
+
+// directMessage is one notification, rendered once for all destinations.
+type directMessage struct {
+	Title   string
+	Text    string // Markdown
+	URL     string
+	Payload FizzyPayload
+	Catalog *catalog
+}
+
+type directDestination struct {
+	kind string
+	send func(ctx context.Context, msg directMessage) error
+}
+
+// destinations lists where the user's direct messages go.
+func (u directoryUser) destinations() []directDestination {
+	var dests []directDestination
+	if u.ZulipPM != "" {
+		dests = append(dests, directDestination{"zulip", func(ctx context.Context, msg directMessage) error {
+			return sendZulipPM(ctx, u.ZulipPM, msg)
+		}})
+	}
+	if u.Gotify != "" {
+		dests = append(dests, directDestination{"gotify", func(ctx context.Context, msg directMessage) error {
+			return sendGotifyDM(ctx, u.Gotify, msg)
+		}})
+	}
+	if u.Ntfy != "" {
+		dests = append(dests, directDestination{"ntfy", func(ctx context.Context, msg directMessage) error {
+			return sendNtfy(ctx, u.Ntfy, msg)
+		}})
+	}
+	if u.Email != "" {
+		dests = append(dests, directDestination{"email", func(ctx context.Context, msg directMessage) error {
+			return sendEmail(u.Email, msg)
+		}})
+	}
+	return dests
+}
+
+// sendZulipPM posts a private message through the Zulip REST API.
+func sendZulipPM(ctx context.Context, to string, msg directMessage) error {
+	var recipient interface{} = to
+	if id, err := strconv.Atoi(to); err == nil {
+		recipient = id
+	}
+	toJSON, _ := json.Marshal([]interface{}{recipient})
+
+	form := url.Values{
+		"type":    {"private"},
+		"to":      {string(toJSON)},
+		"content": {msg.Text},
+	}
+	resp, err := zulipAPI(ctx, directClient, http.MethodPost, "/api/v1/messages", form)
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("status %d: %s", resp.StatusCode, strings.TrimSpace(string(resp.Body)))
+	}
+	return nil
+}
+
+// sendGotifyDM pushes to the user's own Gotify application.
+func sendGotifyDM(ctx context.Context, dest string, msg directMessage) error {
+	endpoint := dest
+	if !strings.Contains(dest, "://") {
+		endpoint = strings.TrimSuffix(os.Getenv("GOTIFY_SERVER"), "/") + "/message?token=" + url.QueryEscape(dest)
+	}
+	body, err := gotifyMessage(msg.Catalog, msg.Payload, msg.Text)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	return doDirect(req)
+}
+
+// sendNtfy publishes to the user's ntfy topic, with the card as click action.
+func sendNtfy(ctx context.Context, topic string, msg directMessage) error {
+	endpoint := topic
+	if !strings.Contains(topic, "://") {
+		endpoint = strings.TrimSuffix(envOrDefault("NTFY_SERVER", defaultNtfyServer), "/") + "/" + url.PathEscape(topic)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, strings.NewReader(msg.Text))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Title", mime.QEncoding.Encode("utf-8", msg.Title))
+	req.Header.Set("Markdown", "yes")
+	if msg.URL != "" {
+		req.Header.Set("Click", msg.URL)
+	}
+	if token := os.Getenv("NTFY_TOKEN"); token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	return doDirect(req)
+}
+
+func doDirect(req *http.Request) error {
+	req.Header.Set("User-Agent", "Fizzy-Proxy/1.0")
+	resp, err := directClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return fmt.Errorf("status %d: %s", resp.StatusCode, strings.TrimSpace(string(body)))
+	}
+	return nil
+}
+
+// sendEmail mails the message through SMTP_ADDR (host:port) from SMTP_FROM,
+// authenticating when SMTP_USERNAME is set. STARTTLS is used when offered.
+func sendEmail(to string, msg directMessage) error {
+	addr := os.Getenv("SMTP_ADDR")
+	from := os.Getenv("SMTP_FROM")
+
+	var auth smtp.Auth
+	if username := os.Getenv("SMTP_USERNAME"); username != "" {
+		host, _, _ := strings.Cut(addr, ":")
+		auth = smtp.PlainAuth("", username, os.Getenv("SMTP_PASSWORD"), host)
+	}
+
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "From: %s\r\n", from)
+	fmt.Fprintf(&sb, "To: %s\r\n", to)
+	fmt.Fprintf(&sb, "Subject: %s\r\n", mime.QEncoding.Encode("utf-8", msg.Title))
+	fmt.Fprintf(&sb, "Date: %s\r\n", time.Now().Format(time.RFC1123Z))
+	sb.WriteString("MIME-Version: 1.0\r\n")
+	sb.WriteString("Content-Type: text/plain; charset=utf-8\r\n")
+	sb.WriteString("Content-Transfer-Encoding: 8bit\r\n\r\n")
+	sb.WriteString(strings.ReplaceAll(msg.Text, "\n", "\r\n"))
+	sb.WriteString("\r\n")
+
+	return smtp.SendMail(addr, auth, from, []string{to}, []byte(sb.String()))
+}
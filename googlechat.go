@@ -0,0 +1,228 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// --- Google Chat Cards ---
+
+// Card styles for Google Chat targets, see {ID}_CARD_STYLE.
+const (
+	cardStyleFull    = "full"
+	cardStyleCompact = "compact"
+)
+
+// collapseAfter is how much of a long comment is shown before the rest is
+// folded behind "Show more".
+const collapseAfter = 280
+
+// loadCardStyle reports whether a target posts compact messages.
+func loadCardStyle(tc targetConfig) (bool, error) {
+	style := strings.ToLower(tc.CardStyle)
+	if style == "" {
+		style = strings.ToLower(envOrDefault("CARD_STYLE", cardStyleFull))
+	}
+	switch style {
+	case cardStyleFull:
+		return false, nil
+	case cardStyleCompact:
+		return true, nil
+	default:
+		return false, fmt.Errorf("unknown card style %q (expected full or compact)", style)
+	}
+}
+
+// translateToGoogleChat renders an event as a card with the comment, the
+// assignee, column and reason, and links to the card, comment and board. In
+// compact mode it is a single line of text, for high-volume spaces.
+func translateToGoogleChat(cat *catalog, f FizzyPayload, compact bool) ([]byte, error) {
+	actor := actorName(cat, f)
+	verb, emoji := prettyAction(cat, f)
+
+	finalURL := resolveFizzyURL(f)
+
+	subjectTitle := f.Eventable.Title
+	if subjectTitle == "" {
+		if f.Board.Name != "" {
+			subjectTitle = f.Board.Name
+		} else {
+			subjectTitle = cat.text("notification")
+		}
+	}
+
+	if compact {
+		link := subjectTitle
+		if finalURL != "" {
+			link = fmt.Sprintf("<%s|%s>", finalURL, subjectTitle)
+		}
+		return json.Marshal(GoogleChatPayload{
+			Text: fmt.Sprintf("%s %s %s: %s", emoji, actor, verb, link),
+		})
+	}
+
+	// Cards cannot render mentions, so only the text above the card has them.
+	cardVerb, _ := prettyAction(cat.withMentions(nil), f)
+	header := CardHeader{
+		Title:    subjectTitle,
+		Subtitle: fmt.Sprintf("%s %s", actor, cardVerb),
+	}
+	if avatar := avatarURL(f.Creator); avatar != "" {
+		header.ImageURL = avatar
+		header.ImageType = "CIRCLE"
+		header.ImageAltText = actor
+	}
+
+	var sections []CardSection
+	if body := f.Eventable.Body.PlainText; body != "" {
+		sections = append(sections, bodySection(body))
+	}
+
+	var widgets []Widget
+	if f.Assignee != nil && f.Assignee.Name != "" {
+		widgets = append(widgets, detailWidget(cat.text("assignee"), f.Assignee.Name, "PERSON"))
+	}
+	if f.Column != nil && f.Column.Name != "" {
+		widgets = append(widgets, detailWidget(cat.text("column"), f.Column.Name, "BOOKMARK"))
+	}
+	if f.Reason != "" {
+		widgets = append(widgets, detailWidget(cat.text("reason"), f.Reason, "DESCRIPTION"))
+	}
+	if f.Board.Name != "" && subjectTitle != f.Board.Name {
+		widgets = append(widgets, detailWidget(cat.text("board"), f.Board.Name, "TICKET"))
+	}
+	if buttons := cardButtons(cat, f, finalURL); len(buttons) > 0 {
+		widgets = append(widgets, Widget{ButtonList: &ButtonList{Buttons: buttons}})
+	}
+	sections = append(sections, CardSection{Widgets: widgets})
+
+	card := CardV2{
+		CardID: fmt.Sprintf("fizzy-%d", time.Now().UnixNano()),
+		Card: Card{
+			Header:   header,
+			Sections: sections,
+		},
+	}
+
+	fallbackText := fmt.Sprintf("%s %s %s: %s", emoji, actor, verb, subjectTitle)
+
+	payload := GoogleChatPayload{
+		Text:    fallbackText,
+		CardsV2: []CardV2{card},
+	}
+	return json.Marshal(payload)
+}
+
+func detailWidget(label, text, icon string) Widget {
+	return Widget{
+		DecoratedText: &DecoratedText{
+			TopLabel:  label,
+			Text:      text,
+			StartIcon: &Icon{KnownIcon: icon},
+		},
+	}
+}
+
+// bodySection shows a comment body. A long body is cut at a word boundary
+// and the rest goes into the collapsed part of the section.
+func bodySection(body string) CardSection {
+	if len(body) <= collapseAfter {
+		return CardSection{Widgets: []Widget{{TextParagraph: &TextParagraph{Text: body}}}}
+	}
+
+	cut := strings.LastIndexAny(body[:collapseAfter], " \n")
+	if cut <= 0 {
+		cut = collapseAfter
+		for cut > 0 && !utf8.RuneStart(body[cut]) {
+			cut--
+		}
+	}
+	return CardSection{
+		Widgets: []Widget{
+			{TextParagraph: &TextParagraph{Text: strings.TrimSpace(body[:cut]) + " …"}},
+			{TextParagraph: &TextParagraph{Text: strings.TrimSpace(body[cut:])}},
+		},
+		Collapsible:               true,
+		UncollapsibleWidgetsCount: 1,
+	}
+}
+
+// cardButtons links to what the event is about: the comment and its card,
+// or the card, and the board.
+func cardButtons(cat *catalog, f FizzyPayload, eventURL string) []Button {
+	var buttons []Button
+	add := func(label, link string) {
+		if link == "" {
+			return
+		}
+		for _, b := range buttons {
+			if b.OnClick.OpenLink.URL == link {
+				return
+			}
+		}
+		buttons = append(buttons, Button{
+			Text:    label,
+			Icon:    &Icon{KnownIcon: "OPEN_IN_NEW"},
+			OnClick: &OnClick{OpenLink: &OpenLink{URL: link}},
+		})
+	}
+
+	switch {
+	case strings.EqualFold(f.Action, "comment_created"):
+		add(cat.text("view_comment"), eventURL)
+		// The comment link is the card with a comment anchor.
+		if i := strings.Index(eventURL, "#comment_"); i > 0 {
+			add(cat.text("view_card"), eventURL[:i])
+		}
+	case cardKey(f) != "":
+		add(cat.text("view_card"), eventURL)
+	default:
+		add(cat.text("view_in_fizzy"), eventURL)
+	}
+	if f.Board.URL != "" {
+		add(cat.text("view_board"), resolveFizzyURL(FizzyPayload{Board: f.Board}))
+	}
+	return buttons
+}
+
+// avatarURL returns the user's picture from the payload, or from the user
+// directory when Fizzy does not send one.
+func avatarURL(u FizzyUser) string {
+	if u.AvatarURL != "" {
+		return u.AvatarURL
+	}
+	if du, ok := users.lookup(u.Name); ok {
+		return du.Avatar
+	}
+	return ""
+}
+
+// --- Google Chat Threads ---
+
+// threadKey names the Google Chat thread of the card an event is about, so
+// card events and comments on the card share it. Returns empty string when
+// the card cannot be determined.
+func threadKey(f FizzyPayload) string {
+	key := cardKey(f)
+	if key == "" {
+		return ""
+	}
+	return "fizzy-card-" + strings.TrimPrefix(key, "card:")
+}
+
+// withThread adds the thread to a rendered Google Chat message, including
+// messages from templates.
+func withThread(body []byte, key string) ([]byte, error) {
+	if key == "" {
+		return body, nil
+	}
+	var msg map[string]interface{}
+	if err := json.Unmarshal(body, &msg); err != nil {
+		return nil, fmt.Errorf("thread: %w", err)
+	}
+	msg["thread"] = map[string]string{"threadKey": key}
+	return json.Marshal(msg)
+}
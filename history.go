@@ -0,0 +1,421 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// --- Event History ---
+
+// Dispositions recorded for incoming events.
+const (
+	dispositionAccepted    = "accepted"     // Handed to delivery; see the event's deliveries
+	dispositionSummaryOnly = "summary_only" // Counted for the scheduled summary only
+	dispositionDuplicate   = "duplicate"
+	dispositionDisabled    = "disabled"
+)
+
+// historyEvent is one received Fizzy payload and what happened to it.
+type historyEvent struct {
+	ID          string            `json:"id"`
+	Time        time.Time         `json:"time"`
+	Target      string            `json:"target"`
+	Disposition string            `json:"disposition"`
+	Payload     FizzyPayload      `json:"payload"`
+	Deliveries  []historyDelivery `json:"deliveries,omitempty"`
+}
+
+// historyDelivery is one attempt to send a message built from the event.
+type historyDelivery struct {
+	Time      time.Time `json:"time"`
+	Status    int       `json:"status,omitempty"`
+	Error     string    `json:"error,omitempty"`
+	LatencyMS int64     `json:"latency_ms"`
+	Events    int       `json:"events"` // Number of events folded into the message
+}
+
+// historyLine is the on-disk format: one JSON object per line, either a new
+// event or a delivery that refers to earlier events by ID.
+type historyLine struct {
+	Event    *historyEvent    `json:"event,omitempty"`
+	Delivery *historyDelivery `json:"delivery,omitempty"`
+	EventIDs []string         `json:"event_ids,omitempty"`
+}
+
+// historyStore persists every received event and its delivery outcomes in
+// an append-only JSON lines file (HISTORY_FILE). Up to maxEvents events are
+// also kept in memory for queries; records older than the retention or
+// beyond that limit are pruned and the file is compacted periodically.
+type historyStore struct {
+	mu        sync.Mutex
+	compactMu sync.Mutex // Serializes compactions, which run mostly without mu
+	path      string
+	retention time.Duration
+	maxEvents int
+	file      *os.File
+	events    []*historyEvent // Oldest first
+	byID      map[string]*historyEvent
+	pending   []historyLine // Lines appended during compaction; nil otherwise
+}
+
+// history is nil when HISTORY_FILE is not set; its methods are no-ops then.
+var history *historyStore
+
+var historySeq uint64
+
+// maxHistoryLineBytes bounds a single line of the history file.
+const maxHistoryLineBytes = 16 << 20
+
+// defaultHistoryMaxEvents is how many events are kept without HISTORY_MAX_EVENTS.
+const defaultHistoryMaxEvents = 100000
+
+// newEventID returns a unique, time-ordered ID for an incoming event.
+func newEventID() string {
+	return fmt.Sprintf("%x-%x", time.Now().UnixNano(), atomic.AddUint64(&historySeq, 1))
+}
+
+func openHistoryStore(path string, retention time.Duration, maxEvents int) (*historyStore, error) {
+	h := &historyStore{
+		path:      path,
+		retention: retention,
+		maxEvents: maxEvents,
+		byID:      make(map[string]*historyEvent),
+	}
+
+	if err := h.load(); err != nil {
+		return nil, err
+	}
+	if err := h.compact(); err != nil {
+		return nil, err
+	}
+	go h.sweep()
+	return h, nil
+}
+
+// load replays the history file into memory.
+func (h *historyStore) load() error {
+	file, err := os.Open(h.path)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 64*1024), maxHistoryLineBytes)
+	for scanner.Scan() {
+		var line historyLine
+		if err := json.Unmarshal(scanner.Bytes(), &line); err != nil {
+			log.Printf("warning: skipping corrupt history line: %v", err)
+			continue
+		}
+		h.apply(line)
+	}
+	return scanner.Err()
+}
+
+// apply adds a line to the in-memory index. Callers hold h.mu (or own h).
+func (h *historyStore) apply(line historyLine) {
+	if line.Event != nil {
+		h.events = append(h.events, line.Event)
+		h.byID[line.Event.ID] = line.Event
+		if len(h.events) > h.maxEvents {
+			delete(h.byID, h.events[0].ID)
+			h.events[0] = nil
+			h.events = h.events[1:]
+		}
+	}
+	if line.Delivery != nil {
+		for _, id := range line.EventIDs {
+			if ev, ok := h.byID[id]; ok {
+				ev.Deliveries = append(ev.Deliveries, *line.Delivery)
+			}
+		}
+	}
+}
+
+// append writes a line to disk and to the in-memory index.
+func (h *historyStore) append(line historyLine) {
+	data, err := json.Marshal(line)
+	if err != nil {
+		log.Printf("history encode error: %v", err)
+		return
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	h.apply(line)
+	if h.pending != nil {
+		h.pending = append(h.pending, line)
+	}
+	if _, err := h.file.Write(append(data, '\n')); err != nil {
+		log.Printf("history write error: %v", err)
+	}
+}
+
+// RecordEvent stores an incoming event and how it was handled.
+func (h *historyStore) RecordEvent(t target, ev event, disposition string) {
+	if h == nil {
+		return
+	}
+	h.append(historyLine{Event: &historyEvent{
+		ID:          ev.ID,
+		Time:        ev.Received,
+		Target:      t.Identifier,
+		Disposition: disposition,
+		Payload:     ev.Payload,
+	}})
+}
+
+// RecordDelivery stores the outcome of a message built from events.
+func (h *historyStore) RecordDelivery(events []event, resp *upstreamResponse, err error, latency time.Duration) {
+	if h == nil || len(events) == 0 {
+		return
+	}
+
+	ids := make([]string, 0, len(events))
+	for _, ev := range events {
+		ids = append(ids, ev.IDs()...)
+	}
+
+	d := historyDelivery{
+		Time:      time.Now(),
+		LatencyMS: latency.Milliseconds(),
+		Events:    len(ids),
+	}
+	if resp != nil {
+		d.Status = resp.StatusCode
+	}
+	if err != nil {
+		d.Error = err.Error()
+	}
+
+	h.append(historyLine{Delivery: &d, EventIDs: ids})
+}
+
+// sweep prunes and compacts the store once an hour.
+func (h *historyStore) sweep() {
+	for range time.Tick(time.Hour) {
+		if err := h.compact(); err != nil {
+			log.Printf("history compaction error: %v", err)
+		}
+	}
+}
+
+// compact drops events older than the retention and rewrites the file with
+// what is left, then reopens it for appending. The file is written from a
+// snapshot without holding h.mu, so incoming events are not blocked; lines
+// appended meanwhile are added to the new file before it replaces the old.
+func (h *historyStore) compact() error {
+	h.compactMu.Lock()
+	defer h.compactMu.Unlock()
+
+	h.mu.Lock()
+	cutoff := time.Now().Add(-h.retention)
+	kept := h.events[:0]
+	for _, ev := range h.events {
+		if ev.Time.Before(cutoff) {
+			delete(h.byID, ev.ID)
+			continue
+		}
+		kept = append(kept, ev)
+	}
+	for i := len(kept); i < len(h.events); i++ {
+		h.events[i] = nil
+	}
+	h.events = kept
+
+	snapshot := make([]historyEvent, len(h.events))
+	for i, ev := range h.events {
+		snapshot[i] = *ev
+	}
+	h.pending = []historyLine{}
+	h.mu.Unlock()
+
+	tmp := h.path + ".tmp"
+	err := writeHistoryFile(tmp, snapshot)
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	pending := h.pending
+	h.pending = nil
+	if err != nil {
+		return err
+	}
+
+	out, err := os.OpenFile(tmp, os.O_APPEND|os.O_WRONLY, 0o600)
+	if err != nil {
+		return err
+	}
+	enc := json.NewEncoder(out)
+	for _, line := range pending {
+		if err := enc.Encode(line); err != nil {
+			out.Close()
+			return err
+		}
+	}
+	if err := os.Rename(tmp, h.path); err != nil {
+		out.Close()
+		return err
+	}
+
+	if h.file != nil {
+		h.file.Close()
+	}
+	h.file = out
+	return nil
+}
+
+// writeHistoryFile writes events, with their deliveries, to a new file.
+func writeHistoryFile(path string, events []historyEvent) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+		return err
+	}
+	out, err := os.OpenFile(path, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0o600)
+	if err != nil {
+		return err
+	}
+	w := bufio.NewWriter(out)
+	enc := json.NewEncoder(w)
+	for i := range events {
+		if err := enc.Encode(historyLine{Event: &events[i]}); err != nil {
+			out.Close()
+			return err
+		}
+	}
+	if err := w.Flush(); err != nil {
+		out.Close()
+		return err
+	}
+	return out.Close()
+}
+
+// --- History Queries ---
+
+// historyQuery filters events; zero values match everything.
+type historyQuery struct {
+	Board  string // Board name or ID
+	Card   string // Card number or card ID
+	Action string
+	Actor  string
+	Target string
+	Since  time.Time
+	Until  time.Time
+	Limit  int
+}
+
+const (
+	defaultHistoryLimit = 100
+	maxHistoryLimit     = 1000
+)
+
+func (q historyQuery) matches(ev *historyEvent) bool {
+	f := ev.Payload
+	switch {
+	case q.Target != "" && ev.Target != q.Target:
+		return false
+	case q.Action != "" && !strings.EqualFold(f.Action, q.Action):
+		return false
+	case q.Actor != "" && !strings.EqualFold(f.Creator.Name, q.Actor):
+		return false
+	case q.Board != "" && !strings.EqualFold(f.Board.Name, q.Board) && f.Board.ID != q.Board:
+		return false
+	case q.Card != "" && !isCard(f, q.Card):
+		return false
+	case !q.Since.IsZero() && ev.Time.Before(q.Since):
+		return false
+	case !q.Until.IsZero() && ev.Time.After(q.Until):
+		return false
+	}
+	return true
+}
+
+// Search returns matching events, newest first.
+func (h *historyStore) Search(q historyQuery) []historyEvent {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	results := []historyEvent{}
+	for i := len(h.events) - 1; i >= 0 && len(results) < q.Limit; i-- {
+		if ev := h.events[i]; q.matches(ev) {
+			results = append(results, *ev)
+		}
+	}
+	return results
+}
+
+// parseHistoryQuery reads filters from the query string. Times are RFC 3339
+// or a duration relative to now (e.g. since=24h).
+func parseHistoryQuery(r *http.Request) (historyQuery, error) {
+	v := r.URL.Query()
+	q := historyQuery{
+		Board:  v.Get("board"),
+		Card:   strings.TrimPrefix(v.Get("card"), "#"),
+		Action: v.Get("action"),
+		Actor:  v.Get("actor"),
+		Target: v.Get("target"),
+		Limit:  defaultHistoryLimit,
+	}
+
+	var err error
+	if q.Since, err = parseQueryTime(v.Get("since")); err != nil {
+		return q, fmt.Errorf("since: %w", err)
+	}
+	if q.Until, err = parseQueryTime(v.Get("until")); err != nil {
+		return q, fmt.Errorf("until: %w", err)
+	}
+
+	if s := v.Get("limit"); s != "" {
+		n, err := strconv.Atoi(s)
+		if err != nil || n < 1 {
+			return q, fmt.Errorf("limit must be a positive number")
+		}
+		q.Limit = n
+	}
+	if q.Limit > maxHistoryLimit {
+		q.Limit = maxHistoryLimit
+	}
+	return q, nil
+}
+
+func parseQueryTime(s string) (time.Time, error) {
+	if s == "" {
+		return time.Time{}, nil
+	}
+	if d, err := time.ParseDuration(s); err == nil {
+		return time.Now().Add(-d), nil
+	}
+	return time.Parse(time.RFC3339, s)
+}
+
+// serveHistory answers GET /admin/history.
+func serveHistory(w http.ResponseWriter, r *http.Request) {
+	if history == nil {
+		writeJSONError(w, http.StatusNotFound, "history is disabled; set HISTORY_FILE")
+		return
+	}
+	if r.Method != http.MethodGet {
+		writeJSONError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+
+	q, err := parseHistoryQuery(r)
+	if err != nil {
+		writeJSONError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	writeJSON(w, http.StatusOK, history.Search(q))
+}
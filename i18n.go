@@ -0,0 +1,294 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+)
+
+// --- Localization ---
+
+// defaultLocale is used when neither {ID}_LOCALE nor LOCALE is set.
+const defaultLocale = "en"
+
+// catalog holds the user-facing wording of one language. Verbs describe what
+// the actor did and are keyed by action (plus a few variants such as
+// "card_moved_to", see actionKey); Text holds everything else. Entries may
+// contain fmt verbs, filled in by the caller.
+//
+// Emojis and the column lists are language independent and only set on the
+// English catalog; a target's catalog may override them (see vocabulary.go).
+type catalog struct {
+	Locale           string
+	Verbs            map[string]string
+	Text             map[string]string
+	Emojis           map[string]string // Keyed like Verbs
+	CompletedColumns []string          // Columns where closing or archiving means "completed"
+	PostponedColumns []string          // Columns where archiving means "postponed"
+	Mentions         map[string]string // Chat mention per lowercased Fizzy name, see users.go
+}
+
+var catalogs = map[string]*catalog{
+	"en": {
+		Locale: "en",
+		Verbs: map[string]string{
+			"comment_created":          "commented",
+			"card_created":             "created a card",
+			"card_published":           "published a card",
+			"card_reopened":            "reopened the card",
+			"card_board_changed":       "changed the card's board",
+			"card_moved":               "moved the card",
+			"card_moved_to":            "moved the card to **%s**",
+			"card_moved_inactivity":    "moved the card to **%s** due to inactivity",
+			"card_assigned":            "assigned the card to someone",
+			"card_assigned_to":         "assigned the card to **%s**",
+			"card_assigned_mention":    "assigned the card to %s",
+			"card_unassigned":          "unassigned the card",
+			"card_postponed":           "postponed the card",
+			"card_closed":              "closed the card",
+			"card_completed":           "completed the card",
+			"card_sent_back_to_triage": "sent the card back to triage",
+			"card_archived":            "archived the card",
+			"card_archived_postponed":  "postponed the card",
+		},
+		Text: map[string]string{
+			"someone":        "Someone",
+			"notification":   "Fizzy Notification",
+			"card_number":    "Card #%s",
+			"board":          "Board",
+			"board_line":     "Board: %s",
+			"view_in_fizzy":  "View in Fizzy",
+			"assignee":       "Assignee",
+			"column":         "Column",
+			"reason":         "Reason",
+			"view_card":      "Open card",
+			"view_comment":   "View comment",
+			"view_board":     "Open board",
+			"and":            "and",
+			"updates":        "%d updates",
+			"digest_title":   "%d Fizzy updates",
+			"summary_title":  "Fizzy summary since %s",
+			"summary_date":   "Mon Jan 2 15:04",
+			"summary_total":  "%d events in total",
+			"summary_events": "%d events",
+			"summary_board":  "%d created, %d closed, %d moved, %d other",
+			"summary_boards": "Boards",
+			"top_commenters": "Top commenters",
+			"other_board":    "Other",
+		},
+		Emojis: map[string]string{
+			"comment_created":          "💬",
+			"card_created":             "🃏",
+			"card_published":           "📢",
+			"card_reopened":            "🔄",
+			"card_board_changed":       "📋",
+			"card_moved":               "🚚",
+			"card_moved_inactivity":    "💤",
+			"card_assigned":            "👤",
+			"card_unassigned":          "👤",
+			"card_postponed":           "💤",
+			"card_closed":              "✅",
+			"card_completed":           "✅",
+			"card_sent_back_to_triage": "↩️",
+			"card_archived":            "📦",
+			"card_archived_postponed":  "😴",
+		},
+		CompletedColumns: []string{"Done"},
+		PostponedColumns: []string{"Postponed", "Not Now"},
+	},
+	"tr": {
+		Locale: "tr",
+		Verbs: map[string]string{
+			"comment_created":          "yorum yaptı",
+			"card_created":             "bir kart oluşturdu",
+			"card_published":           "bir kart yayınladı",
+			"card_reopened":            "kartı yeniden açtı",
+			"card_board_changed":       "kartın panosunu değiştirdi",
+			"card_moved":               "kartı taşıdı",
+			"card_moved_to":            "kartı **%s** sütununa taşıdı",
+			"card_moved_inactivity":    "kartı hareketsizlik nedeniyle **%s** sütununa taşıdı",
+			"card_assigned":            "kartı birine atadı",
+			"card_assigned_to":         "kartı **%s** kişisine atadı",
+			"card_assigned_mention":    "kartı %s kişisine atadı",
+			"card_unassigned":          "kartın atamasını kaldırdı",
+			"card_postponed":           "kartı erteledi",
+			"card_closed":              "kartı kapattı",
+			"card_completed":           "kartı tamamladı",
+			"card_sent_back_to_triage": "kartı triyaja geri gönderdi",
+			"card_archived":            "kartı arşivledi",
+			"card_archived_postponed":  "kartı erteledi",
+		},
+		Text: map[string]string{
+			"someone":        "Birisi",
+			"notification":   "Fizzy Bildirimi",
+			"card_number":    "Kart #%s",
+			"board":          "Pano",
+			"board_line":     "Pano: %s",
+			"view_in_fizzy":  "Fizzy'de görüntüle",
+			"assignee":       "Atanan",
+			"column":         "Sütun",
+			"reason":         "Neden",
+			"view_card":      "Kartı aç",
+			"view_comment":   "Yorumu görüntüle",
+			"view_board":     "Panoyu aç",
+			"and":            "ve",
+			"updates":        "%d güncelleme",
+			"digest_title":   "%d Fizzy güncellemesi",
+			"summary_title":  "%s tarihinden beri Fizzy özeti",
+			"summary_date":   "02.01.2006 15:04",
+			"summary_total":  "Toplam %d olay",
+			"summary_events": "%d olay",
+			"summary_board":  "%d oluşturuldu, %d kapatıldı, %d taşındı, %d diğer",
+			"summary_boards": "Panolar",
+			"top_commenters": "En çok yorum yapanlar",
+			"other_board":    "Diğer",
+		},
+	},
+}
+
+// defaultCatalog is the English catalog. Lookups missing from another
+// catalog fall back to it.
+var defaultCatalog = catalogs[defaultLocale]
+
+// loadCatalog returns the catalog for the target's locale, falling back to
+// LOCALE and then English, with the target's vocabulary applied. Region
+// suffixes are ignored, so "tr-TR" and "tr_TR" select "tr".
+func loadCatalog(tc targetConfig) (*catalog, error) {
+	locale := tc.Locale
+	if locale == "" {
+		locale = envOrDefault("LOCALE", defaultLocale)
+	}
+	lang := strings.ToLower(locale)
+	if i := strings.IndexAny(lang, "-_"); i > 0 {
+		lang = lang[:i]
+	}
+	c, ok := catalogs[lang]
+	if !ok {
+		return nil, fmt.Errorf("unknown locale %q (available: %s)", locale, strings.Join(availableLocales(), ", "))
+	}
+
+	vocabularies, err := targetVocabularies(tc)
+	if err != nil {
+		return nil, err
+	}
+	for _, v := range vocabularies {
+		c = c.withVocabulary(v)
+	}
+	return c, nil
+}
+
+func availableLocales() []string {
+	locales := make([]string, 0, len(catalogs))
+	for locale := range catalogs {
+		locales = append(locales, locale)
+	}
+	sort.Strings(locales)
+	return locales
+}
+
+// verb returns the wording for an action key. Unknown actions are shown as
+// their name, e.g. "card_pinned" becomes "card pinned".
+func (c *catalog) verb(key string, args ...interface{}) string {
+	format, ok := c.or().Verbs[key]
+	if !ok {
+		if format, ok = defaultCatalog.Verbs[key]; !ok {
+			return strings.ReplaceAll(key, "_", " ")
+		}
+	}
+	return sprintf(format, args...)
+}
+
+// hasVerb reports whether key has wording, in this catalog or in English.
+func (c *catalog) hasVerb(key string) bool {
+	_, ok := c.or().Verbs[key]
+	if !ok {
+		_, ok = defaultCatalog.Verbs[key]
+	}
+	return ok
+}
+
+// emoji returns the emoji for a verb key, falling back to the emoji of the
+// plain action and then to a generic one.
+func (c *catalog) emoji(key, action string) string {
+	for _, k := range []string{key, action} {
+		if e, ok := c.or().Emojis[k]; ok {
+			return e
+		}
+		if e, ok := defaultCatalog.Emojis[k]; ok {
+			return e
+		}
+	}
+	return "📢"
+}
+
+// isCompleted reports whether column is one of the completed columns.
+func (c *catalog) isCompleted(column string) bool {
+	columns := c.or().CompletedColumns
+	if columns == nil {
+		columns = defaultCatalog.CompletedColumns
+	}
+	return containsFold(columns, column)
+}
+
+// isPostponed reports whether column is one of the postponed columns.
+func (c *catalog) isPostponed(column string) bool {
+	columns := c.or().PostponedColumns
+	if columns == nil {
+		columns = defaultCatalog.PostponedColumns
+	}
+	return containsFold(columns, column)
+}
+
+func containsFold(items []string, s string) bool {
+	for _, item := range items {
+		if strings.EqualFold(item, s) {
+			return true
+		}
+	}
+	return false
+}
+
+// mention returns the chat mention for a Fizzy user, if the directory has
+// one for the target's platform.
+func (c *catalog) mention(name string) (string, bool) {
+	m, ok := c.or().Mentions[strings.ToLower(strings.TrimSpace(name))]
+	return m, ok
+}
+
+// withMentions returns a copy of c that mentions users with the given markup.
+// withMentions(nil) gives plain names, e.g. for card widgets that cannot
+// render mentions.
+func (c *catalog) withMentions(mentions map[string]string) *catalog {
+	merged := *c.or()
+	merged.Mentions = mentions
+	return &merged
+}
+
+// text returns a localized string, falling back to English.
+func (c *catalog) text(key string, args ...interface{}) string {
+	format, ok := c.or().Text[key]
+	if !ok {
+		if format, ok = defaultCatalog.Text[key]; !ok {
+			log.Printf("warning: no text for %q", key)
+			return key
+		}
+	}
+	return sprintf(format, args...)
+}
+
+// or treats a nil catalog as English, e.g. for targets built outside
+// buildTarget.
+func (c *catalog) or() *catalog {
+	if c == nil {
+		return defaultCatalog
+	}
+	return c
+}
+
+func sprintf(format string, args ...interface{}) string {
+	if len(args) == 0 {
+		return format
+	}
+	return fmt.Sprintf(format, args...)
+}
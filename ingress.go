@@ -0,0 +1,136 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/netip"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// --- Ingress Restrictions ---
+
+// defaultMaxBodyBytes bounds webhook request bodies unless MAX_BODY_BYTES is
+// set. Fizzy payloads are a few kilobytes.
+const defaultMaxBodyBytes = 1 << 20
+
+var (
+	maxBodyBytes   = int64(defaultMaxBodyBytes)
+	trustedProxies []netip.Prefix // TRUSTED_PROXIES; X-Forwarded-For is only read from these
+)
+
+// loadIngressSettings reads MAX_BODY_BYTES and TRUSTED_PROXIES.
+func loadIngressSettings() error {
+	if v := os.Getenv("MAX_BODY_BYTES"); v != "" {
+		n, err := strconv.ParseInt(v, 10, 64)
+		if err != nil || n < 1 {
+			return fmt.Errorf("MAX_BODY_BYTES must be a positive number of bytes, got %q", v)
+		}
+		maxBodyBytes = n
+	}
+
+	var err error
+	if trustedProxies, err = parsePrefixes(splitList(os.Getenv("TRUSTED_PROXIES"))); err != nil {
+		return fmt.Errorf("TRUSTED_PROXIES: %w", err)
+	}
+	return nil
+}
+
+// parsePrefixes parses CIDR ranges; plain addresses match only themselves.
+func parsePrefixes(items []string) ([]netip.Prefix, error) {
+	var prefixes []netip.Prefix
+	for _, item := range items {
+		if !strings.Contains(item, "/") {
+			addr, err := netip.ParseAddr(item)
+			if err != nil {
+				return nil, fmt.Errorf("invalid address or CIDR %q", item)
+			}
+			prefixes = append(prefixes, netip.PrefixFrom(addr.Unmap(), addr.Unmap().BitLen()))
+			continue
+		}
+		prefix, err := netip.ParsePrefix(item)
+		if err != nil {
+			return nil, fmt.Errorf("invalid address or CIDR %q", item)
+		}
+		prefixes = append(prefixes, prefix.Masked())
+	}
+	return prefixes, nil
+}
+
+func containsAddr(prefixes []netip.Prefix, addr netip.Addr) bool {
+	for _, prefix := range prefixes {
+		if prefix.Contains(addr) {
+			return true
+		}
+	}
+	return false
+}
+
+// clientAddr returns the address of the client. X-Forwarded-For is only
+// honored when the connection comes from a trusted proxy; it is walked from
+// the right and the first address that is not a trusted proxy wins.
+func clientAddr(r *http.Request) netip.Addr {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		host = r.RemoteAddr
+	}
+	addr, err := netip.ParseAddr(host)
+	if err != nil {
+		return netip.Addr{}
+	}
+	addr = addr.Unmap()
+
+	if !containsAddr(trustedProxies, addr) {
+		return addr
+	}
+	hops := strings.Split(strings.Join(r.Header.Values("X-Forwarded-For"), ","), ",")
+	for i := len(hops) - 1; i >= 0; i-- {
+		hop, err := netip.ParseAddr(strings.TrimSpace(hops[i]))
+		if err != nil {
+			break
+		}
+		addr = hop.Unmap()
+		if !containsAddr(trustedProxies, addr) {
+			break
+		}
+	}
+	return addr
+}
+
+// allowsClient reports whether the target accepts requests from r's client.
+// Targets without an allowlist accept everyone.
+func (t *target) allowsClient(r *http.Request) bool {
+	return len(t.AllowedIPs) == 0 || containsAddr(t.AllowedIPs, clientAddr(r))
+}
+
+// readWebhookBody reads a request body of at most maxBodyBytes. It answers
+// the request itself and returns false when the body cannot be used.
+func readWebhookBody(w http.ResponseWriter, r *http.Request) ([]byte, bool) {
+	defer r.Body.Close()
+
+	body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxBodyBytes))
+	var tooLarge *http.MaxBytesError
+	switch {
+	case errors.As(err, &tooLarge):
+		writeWebhookError(w, r, http.StatusRequestEntityTooLarge, codePayloadTooLarge, fmt.Sprintf("request body larger than %d bytes", maxBodyBytes))
+		return nil, false
+	case err != nil:
+		writeWebhookError(w, r, http.StatusBadRequest, codeUnreadableBody, "failed to read request body")
+		return nil, false
+	}
+	return body, true
+}
+
+// requirePost answers non-POST requests with 405 and returns false.
+func requirePost(w http.ResponseWriter, r *http.Request) bool {
+	if r.Method == http.MethodPost {
+		return true
+	}
+	w.Header().Set("Allow", http.MethodPost)
+	writeWebhookError(w, r, http.StatusMethodNotAllowed, codeMethodNotAllowed, "only POST is allowed")
+	return false
+}
@@ -3,15 +3,20 @@ package main
 import (
 	"bufio"
 	"bytes"
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"log"
 	"net/http"
+	"net/netip"
 	"net/url"
 	"os"
+	"strconv"
 	"strings"
 	"sync"
+	"text/template"
 	"time"
 )
 
@@ -26,11 +31,25 @@ const (
 )
 
 type target struct {
-	Name       string
-	Path       string // The path to listen on (includes token prefix if set)
-	URL        string
-	Type       TargetType
-	Identifier string // The identifier from config (e.g., "zulip", "eng-team")
+	Name           string
+	Path           string // The path to listen on, after the /{TOKEN} prefix
+	URL            string
+	Type           TargetType
+	Identifier     string // The identifier from config (e.g., "zulip", "eng-team")
+	Disabled       bool
+	Config         targetConfig // Declarative settings the target was built from
+	Client         *http.Client
+	Limiter        *rateLimiter       // nil when the target is not rate limited
+	Aggregator     *aggregator        // nil when per-card aggregation is disabled
+	Summary        *summarizer        // nil when no scheduled summary is configured
+	Quiet          *quietHours        // nil when the target has no quiet hours
+	AllowedIPs     []netip.Prefix     // Client ranges allowed to post; empty allows all
+	Template       *template.Template // nil when the built-in formatting is used
+	CompactCards   bool               // Google Chat: one line of text instead of a card
+	ThreadByCard   bool               // Google Chat: one thread per card
+	Status         *statusMessages    // Zulip: nil unless card messages are edited in place
+	Catalog        *catalog           // Wording in the target's locale
+	DirectMessages map[string]bool    // Kinds of direct messages sent, e.g. "assigned"; nil when off
 }
 
 // --- Fizzy Payload Types (Generic JSON) ---
@@ -79,7 +98,8 @@ type FizzyEventable struct {
 }
 
 type FizzyUser struct {
-	Name string `json:"name"`
+	Name      string `json:"name"`
+	AvatarURL string `json:"avatar_url,omitempty"`
 }
 
 // --- Destination Payload Types ---
@@ -104,14 +124,18 @@ type Card struct {
 }
 
 type CardHeader struct {
-	Title    string `json:"title"`
-	Subtitle string `json:"subtitle"`
-	ImageURL string `json:"imageUrl,omitempty"`
+	Title        string `json:"title"`
+	Subtitle     string `json:"subtitle"`
+	ImageURL     string `json:"imageUrl,omitempty"`
+	ImageType    string `json:"imageType,omitempty"` // "CIRCLE" or "SQUARE"
+	ImageAltText string `json:"imageAltText,omitempty"`
 }
 
 type CardSection struct {
-	Header  string   `json:"header,omitempty"`
-	Widgets []Widget `json:"widgets"`
+	Header                    string   `json:"header,omitempty"`
+	Widgets                   []Widget `json:"widgets"`
+	Collapsible               bool     `json:"collapsible,omitempty"`
+	UncollapsibleWidgetsCount int      `json:"uncollapsibleWidgetsCount,omitempty"` // Widgets shown while collapsed
 }
 
 type Widget struct {
@@ -172,7 +196,6 @@ var (
 	dedupeCache = make(map[DedupeKey]time.Time)
 	dedupeMu    sync.Mutex
 	debugMode   bool
-	authToken   string // Global TOKEN for URL prefix
 )
 
 func isDuplicate(targetName, action, eventableID string) bool {
@@ -222,38 +245,141 @@ func detectTargetType(webhookURL string) TargetType {
 	return ""
 }
 
+// knownTargetType reports whether the proxy can translate for targetType.
+func knownTargetType(targetType TargetType) bool {
+	switch targetType {
+	case TargetZulip, TargetGoogleChat, TargetGotify:
+		return true
+	}
+	return false
+}
+
 // --- Main Handler ---
 
 func main() {
 	loadDotEnv(".env")
+	os.Exit(runCommand(os.Args[1:]))
+}
 
+// serve runs the proxy server until it fails.
+func serve() {
 	port := envOrDefault("PORT", "3499") // "FIZZ" on phone keypad
 	debugMode = os.Getenv("DEBUG") == "true"
-	authToken = os.Getenv("TOKEN")
 
-	if authToken == "" {
-		log.Fatal("TOKEN is required; set TOKEN in environment for URL prefix security")
+	if err := loadIngressSettings(); err != nil {
+		log.Fatal(err)
+	}
+	if err := loadUserDirectory(); err != nil {
+		log.Fatal(err)
+	}
+
+	var errs []error
+	if tokens, errs = loadTokens(); len(errs) > 0 {
+		log.Fatalf("invalid token configuration, %d problem(s):%s", len(errs), formatConfigErrors(errs))
 	}
 
-	targets := loadTargets()
+	store, err := openConfigStore(os.Getenv("CONFIG_STORE"))
+	if err != nil {
+		log.Fatalf("config store: %v", err)
+	}
+
+	targets, errs := loadTargets(store)
+	if len(errs) > 0 {
+		log.Fatalf("invalid configuration, %d problem(s):%s", len(errs), formatConfigErrors(errs))
+	}
 	if len(targets) == 0 {
 		log.Println("no webhook targets configured; set <IDENTIFIER>_URL in environment")
 	}
 
-	mux := http.NewServeMux()
+	reg := newRegistry()
 	for _, t := range targets {
-		t := t // capture
-		mux.HandleFunc(t.Path, func(w http.ResponseWriter, r *http.Request) {
-			forwardRequest(w, r, t)
-		})
+		reg.put(t)
 		// Log full path with token only in service output
-		log.Printf("routing %s -> %s (%s)", t.Path, t.URL, t.Type)
+		log.Printf("routing %s -> %s (%s)", t.route(), t.URL, t.Type)
+	}
+	for _, tok := range tokens {
+		logToken(tok, reg)
+	}
+
+	if v := os.Getenv("DASHBOARD_HISTORY"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 1 {
+			log.Fatalf("DASHBOARD_HISTORY must be a positive number, got %q", v)
+		}
+		deliveries = newDeliveryLog(n)
+	}
+
+	if path := os.Getenv("HISTORY_FILE"); path != "" {
+		retention := 30 * 24 * time.Hour
+		if v := os.Getenv("HISTORY_RETENTION"); v != "" {
+			if retention, err = time.ParseDuration(v); err != nil || retention <= 0 {
+				log.Fatalf("HISTORY_RETENTION must be a positive duration, got %q", v)
+			}
+		}
+		maxEvents := defaultHistoryMaxEvents
+		if v := os.Getenv("HISTORY_MAX_EVENTS"); v != "" {
+			if maxEvents, err = strconv.Atoi(v); err != nil || maxEvents < 1 {
+				log.Fatalf("HISTORY_MAX_EVENTS must be a positive number, got %q", v)
+			}
+		}
+		if history, err = openHistoryStore(path, retention, maxEvents); err != nil {
+			log.Fatalf("history store: %v", err)
+		}
+		log.Printf("event history enabled (%s, retention %s)", path, retention)
+	}
+
+	mux := http.NewServeMux()
+
+	var dash *dashboard
+	if adminToken := os.Getenv("ADMIN_TOKEN"); adminToken != "" {
+		admin := &adminAPI{token: adminToken, registry: reg, store: store}
+		mux.Handle("/admin/", admin)
+		log.Printf("admin API enabled at /admin/targets")
+		if store.path == "" {
+			log.Printf("warning: CONFIG_STORE is not set; admin changes are lost on restart")
+		}
+
+		dash = newDashboard(adminToken, reg)
+		mux.Handle("/dashboard/", dash)
+		log.Printf("dashboard enabled at /")
 	}
 
 	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		t, preview, err := routeWebhook(reg, r)
+		if t != nil {
+			r = withCorrelationID(w, r)
+		}
+		switch {
+		case t != nil && !t.allowsClient(r):
+			log.Printf("[WARN] Rejected request %s for %s from %s: address not allowed", correlationID(r), t.Identifier, clientAddr(r))
+			writeWebhookError(w, r, http.StatusForbidden, codeForbidden, "forbidden")
+			return
+		case errors.Is(err, errNoCredentials):
+			log.Printf("[WARN] Rejected request %s for %s: %v", correlationID(r), t.Identifier, err)
+			w.Header().Set("WWW-Authenticate", `Bearer realm="fizzy-webhook-proxy"`)
+			writeWebhookError(w, r, http.StatusUnauthorized, codeUnauthorized, "unauthorized")
+			return
+		case err != nil:
+			log.Printf("[WARN] Rejected request %s for %s: %v", correlationID(r), t.Identifier, err)
+			writeWebhookError(w, r, http.StatusForbidden, codeForbidden, "forbidden")
+			return
+		case t != nil && preview:
+			previewRequest(w, r, *t)
+			return
+		case t != nil:
+			forwardRequest(w, r, *t)
+			return
+		}
+
+		if dash != nil && r.URL.Path == "/" {
+			dash.ServeHTTP(w, r)
+			return
+		}
+
 		if debugMode {
 			log.Printf("[DEBUG] Received request on root handler: %s %s", r.Method, r.URL.Path)
 		}
+		targets := reg.list()
 		w.WriteHeader(http.StatusOK)
 		if len(targets) == 0 {
 			fmt.Fprintln(w, "Fizzy webhook proxy: no targets configured")
@@ -263,24 +389,52 @@ func main() {
 		for _, t := range targets {
 			// Show path without token in browser (just /identifier)
 			displayPath := "/" + t.Identifier
-			fmt.Fprintf(w, " - %s (%s) at %s\n", t.Name, t.Type, displayPath)
+			status := ""
+			if t.Disabled {
+				status = " [disabled]"
+			}
+			fmt.Fprintf(w, " - %s (%s) at %s%s\n", t.Name, t.Type, displayPath, status)
 		}
 	})
 
-	log.Printf("listening on :%s", port)
-	if authToken != "" {
-		log.Printf("TOKEN authentication enabled (prefix: /%s/...)", authToken)
-	}
-	if err := http.ListenAndServe(":"+port, mux); err != nil {
+	log.Printf("fizzy-webhook-proxy %s listening on :%s", version, port)
+	if err := listenAndServe(port, mux); err != nil {
 		log.Fatalf("server error: %v", err)
 	}
 }
 
-// loadTargets scans environment variables for webhook configurations.
+// loadTargets scans environment variables for webhook configurations, applies
+// the changes recorded in the config store and builds the targets.
 // Pattern: {IDENTIFIER}_URL and optionally {IDENTIFIER}_TYPE
 // Example: ZULIP_URL, STATUS_PAGE_URL + STATUS_PAGE_TYPE=gotify
-func loadTargets() []target {
-	var targets []target
+// Targets that cannot be built are left out and reported as errors.
+func loadTargets(store *configStore) ([]*target, []error) {
+	var targets []*target
+
+	configs, errs := loadTargetConfigs()
+	for _, cfg := range store.apply(configs) {
+		t, err := buildTarget(cfg)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("target %s: %w", cfg.Identifier, err))
+			continue
+		}
+		targets = append(targets, t)
+	}
+
+	return targets, errs
+}
+
+// globalURLVars are settings that end in _URL but are not webhook targets.
+var globalURLVars = map[string]bool{
+	"FIZZY_ROOT_URL":     true,
+	"ACME_DIRECTORY_URL": true,
+}
+
+// loadTargetConfigs reads target configurations from {IDENTIFIER}_* variables.
+func loadTargetConfigs() ([]targetConfig, []error) {
+	var configs []targetConfig
+	var errs []error
+	seen := make(map[string]string) // Identifier -> variable that defined it
 
 	// Regex to find *_URL variables (but not ending with just _URL which would be empty identifier)
 	urlSuffix := "_URL"
@@ -305,7 +459,7 @@ func loadTargets() []target {
 		}
 
 		// Skip special env vars
-		if key == "FIZZY_ROOT_URL" {
+		if globalURLVars[key] {
 			continue
 		}
 
@@ -314,49 +468,26 @@ func loadTargets() []target {
 		if identifier == "" {
 			continue
 		}
-
-		// Convert to lowercase with hyphens for URL path
-		// Example: STATUS_PAGE -> status-page
-		pathIdentifier := strings.ToLower(strings.ReplaceAll(identifier, "_", "-"))
-
-		// Determine target type
-		var targetType TargetType
-
-		// First check if TYPE is explicitly set
-		typeKey := identifier + "_TYPE"
-		if explicitType := os.Getenv(typeKey); explicitType != "" {
-			targetType = TargetType(strings.ToLower(explicitType))
-		} else {
-			// Try to detect from URL
-			targetType = detectTargetType(value)
-		}
-
-		// If still no type, skip with warning
-		if targetType == "" {
-			log.Printf("warning: cannot detect type for %s, set %s_TYPE explicitly", key, identifier)
+		if identifier == "TOKEN" {
+			errs = append(errs, fmt.Errorf("%s: the TOKEN prefix is reserved for webhook tokens; choose another identifier", key))
 			continue
 		}
 
-		// Build path with optional token prefix
-		var path string
-		if authToken != "" {
-			path = fmt.Sprintf("/%s/%s", authToken, pathIdentifier)
-		} else {
-			path = fmt.Sprintf("/%s", pathIdentifier)
+		cfg, err := targetConfigFromEnv(identifier, value)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", key, err))
+			continue
 		}
-
-		t := target{
-			Name:       pathIdentifier,
-			Path:       path,
-			URL:        value,
-			Type:       targetType,
-			Identifier: pathIdentifier,
+		if other, ok := seen[cfg.Identifier]; ok {
+			errs = append(errs, fmt.Errorf("%s and %s both map to path /%s", other, key, cfg.Identifier))
+			continue
 		}
+		seen[cfg.Identifier] = key
 
-		targets = append(targets, t)
+		configs = append(configs, cfg)
 	}
 
-	return targets
+	return configs, errs
 }
 
 func forwardRequest(w http.ResponseWriter, r *http.Request, t target) {
@@ -364,191 +495,354 @@ func forwardRequest(w http.ResponseWriter, r *http.Request, t target) {
 		log.Printf("[DEBUG] Received request on forward handler (%s): %s %s", t.Name, r.Method, r.URL.Path)
 	}
 	if t.URL == "" {
-		http.Error(w, "target URL not configured", http.StatusServiceUnavailable)
+		writeWebhookError(w, r, http.StatusServiceUnavailable, codeTargetUnavailable, "target URL not configured")
+		return
+	}
+	if !requirePost(w, r) {
 		return
 	}
 
 	// Read original body
-	body, err := io.ReadAll(r.Body)
-	if err != nil {
-		http.Error(w, "failed to read request body", http.StatusBadRequest)
+	body, ok := readWebhookBody(w, r)
+	if !ok {
 		return
 	}
-	defer r.Body.Close()
 
 	// Parse Fizzy Payload
 	var fizzy FizzyPayload
 	if err := json.Unmarshal(body, &fizzy); err != nil {
-		log.Printf("error parsing fizzy payload: %v", err)
-		http.Error(w, fmt.Sprintf("invalid fizzy json: %v. Body was: %s", err, string(body)), http.StatusBadRequest)
+		log.Printf("error parsing fizzy payload %s for %s: %v", correlationID(r), t.Name, err)
+		writeWebhookError(w, r, http.StatusBadRequest, codeInvalidPayload, "request body is not a valid Fizzy webhook payload")
 		return
 	}
 
+	ev := event{
+		ID:       correlationID(r),
+		Payload:  fizzy,
+		Raw:      body,
+		RawQuery: r.URL.RawQuery,
+		Received: time.Now(),
+	}
+
+	outcome, resp, err := processEvent(r.Context(), t, ev)
+	switch {
+	case err != nil && outcome == outcomeSent:
+		writeWebhookError(w, r, http.StatusBadGateway, codeUpstreamUnreachable, "the destination could not be reached")
+	case err != nil:
+		writeWebhookError(w, r, http.StatusInternalServerError, codeTranslationFailed, "the message could not be built")
+	case outcome == outcomeSent && resp.StatusCode >= 300:
+		log.Printf("upstream rejected event %s for %s with status %d", ev.ID, t.Name, resp.StatusCode)
+		writeWebhookError(w, r, http.StatusBadGateway, codeUpstreamRejected, "the destination rejected the message")
+	case outcome == outcomeSent, outcome == outcomeDisabled, outcome == outcomeDuplicate:
+		writeWebhookStatus(w, r, http.StatusOK, outcome) // Success, so Fizzy doesn't retry
+	default:
+		writeWebhookStatus(w, r, http.StatusAccepted, outcome)
+	}
+}
+
+// Outcomes of processEvent.
+const (
+	outcomeSent        = "sent"
+	outcomeDisabled    = "disabled"
+	outcomeDuplicate   = "duplicate"
+	outcomeSummaryOnly = "summary_only"
+	outcomeQuietHours  = "held_for_quiet_hours"
+	outcomeAggregating = "held_for_aggregation"
+	outcomeRateLimited = "queued_by_rate_limit"
+)
+
+// processEvent runs an incoming event through the target's pipeline:
+// dedupe, summaries, quiet hours, aggregation, rate limiting and finally
+// delivery. The upstream response is only set when the event was sent right
+// away; an error with outcomeSent means the upstream request failed.
+func processEvent(ctx context.Context, t target, ev event) (string, *upstreamResponse, error) {
+	fizzy := ev.Payload
+
+	if t.Disabled {
+		log.Printf("[INFO] Dropping event for disabled target: Target=%s Action=%s", t.Name, fizzy.Action)
+		history.RecordEvent(t, ev, dispositionDisabled)
+		return outcomeDisabled, nil, nil
+	}
+
 	// Deduplication Check
 	if isDuplicate(t.Name, fizzy.Action, fizzy.Eventable.ID) {
 		log.Printf("[INFO] Dropping duplicate event: Target=%s Action=%s ID=%s", t.Name, fizzy.Action, fizzy.Eventable.ID)
-		w.WriteHeader(http.StatusOK) // Return success to Fizzy so it doesn't retry
-		return
+		history.RecordEvent(t, ev, dispositionDuplicate)
+		return outcomeDuplicate, nil, nil
+	}
+
+	// Scheduled Summary: count the event, and stop here in summary-only mode.
+	// Test events skip the summary so that they are always delivered.
+	if t.Summary != nil && !ev.Synthetic {
+		t.Summary.Record(fizzy)
+		if t.Summary.only {
+			history.RecordEvent(t, ev, dispositionSummaryOnly)
+			return outcomeSummaryOnly, nil, nil
+		}
+	}
+
+	// Direct Messages: sent apart from the channel message, after quiet hours
+	if t.DirectMessages != nil {
+		sendDirectMessages(t, ev)
+	}
+
+	// Recorded before it can be queued, so deliveries from background
+	// flushes always find the event in the history.
+	history.RecordEvent(t, ev, dispositionAccepted)
+
+	// Quiet Hours: non-urgent events are held until the window closes
+	if t.Quiet != nil && t.Quiet.Hold(ev) {
+		log.Printf("[INFO] Quiet hours, holding event: Target=%s Action=%s ID=%s", t.Name, fizzy.Action, fizzy.Eventable.ID)
+		return outcomeQuietHours, nil, nil
+	}
+
+	// Aggregation: events on the same card are merged and delivered later
+	if t.Aggregator != nil && t.Aggregator.Add(ev) {
+		if debugMode {
+			log.Printf("[DEBUG] Holding event for aggregation: Target=%s Action=%s Card=%s", t.Name, fizzy.Action, cardKey(fizzy))
+		}
+		return outcomeAggregating, nil, nil
+	}
+
+	// Rate Limiting: over-limit events are queued and delivered later as a digest
+	if t.Limiter != nil && !t.Limiter.Allow(ev) {
+		log.Printf("[INFO] Rate limit reached, queued event: Target=%s Action=%s ID=%s", t.Name, fizzy.Action, fizzy.Eventable.ID)
+		return outcomeRateLimited, nil, nil
 	}
 
 	// Translate Payload
-	var newBody []byte
-	var translateErr error
+	events := []event{ev}
+	newBody, err := translateEvents(t, events)
+	if err != nil {
+		log.Printf("translation error for event %s to %s: %v", ev.ID, t.Name, err)
+		return "", nil, err
+	}
+
+	resp, err := deliver(ctx, t, events, newBody)
+	if err != nil {
+		log.Printf("forward error for event %s (%s): %v", ev.ID, t.Name, err)
+		return outcomeSent, nil, err
+	}
+	return outcomeSent, resp, nil
+}
+
+// --- Delivery ---
+
+// event is a parsed Fizzy webhook waiting to be delivered to a target.
+type event struct {
+	ID        string // History ID assigned on receipt
+	Payload   FizzyPayload
+	Raw       []byte // Original request body, used for passthrough targets
+	RawQuery  string // Query string of the inbound request, appended upstream
+	Received  time.Time
+	Merged    []FizzyPayload // All payloads when several events were aggregated
+	MergedIDs []string       // History IDs of the aggregated events
+	Synthetic bool           // Sent by test-target; kept out of summaries
+}
+
+// IDs returns the history IDs of all events this event stands for.
+func (ev event) IDs() []string {
+	if len(ev.MergedIDs) > 0 {
+		return ev.MergedIDs
+	}
+	return []string{ev.ID}
+}
+
+// upstreamResponse is what the destination answered to a forwarded message.
+type upstreamResponse struct {
+	StatusCode int
+	Body       []byte
+}
+
+// translate renders a single event in the format expected by the target.
+func translate(t target, ev event) ([]byte, error) {
+	body, err := translateEvent(t, ev)
+	if err != nil || !t.ThreadByCard {
+		return body, err
+	}
+	return withThread(body, threadKey(ev.Payload))
+}
+
+func translateEvent(t target, ev event) ([]byte, error) {
+	if len(ev.Merged) > 1 {
+		return translateMerged(t, ev)
+	}
+	if t.Template != nil {
+		return translateWithTemplate(t, ev.Payload)
+	}
 
 	switch t.Type {
 	case TargetZulip:
-		newBody, translateErr = translateToZulip(fizzy)
+		return translateToZulip(t.Catalog, ev.Payload)
 	case TargetGoogleChat:
-		newBody, translateErr = translateToGoogleChat(fizzy)
+		return translateToGoogleChat(t.Catalog, ev.Payload, t.CompactCards)
 	case TargetGotify:
-		newBody, translateErr = translateToGotify(fizzy)
+		return translateToGotify(t.Catalog, ev.Payload)
 	default:
-		newBody = body
+		return ev.Raw, nil
 	}
+}
 
-	if translateErr != nil {
-		log.Printf("translation error for %s: %v", t.Name, translateErr)
-		http.Error(w, "translation failed", http.StatusInternalServerError)
-		return
+// translateEvents renders one or more events as a single message. A single
+// event is translated as usual; several events become one digest message.
+func translateEvents(t target, events []event) ([]byte, error) {
+	if len(events) == 1 {
+		return translate(t, events[0])
+	}
+	return translateDigest(t, events)
+}
+
+// deliver sends an already translated body for events to the target and
+// records the outcome for the dashboard. events is empty for messages that
+// are not tied to incoming events, such as scheduled summaries.
+func deliver(ctx context.Context, t target, events []event, body []byte) (*upstreamResponse, error) {
+	rawQuery := ""
+	if len(events) > 0 {
+		rawQuery = events[len(events)-1].RawQuery
 	}
 
+	start := time.Now()
+	var resp *upstreamResponse
+	var err error
+	if t.Status != nil && t.Status.handles(events) {
+		resp, err = t.Status.deliver(ctx, t, events[0], body)
+	} else {
+		resp, err = sendUpstream(ctx, t, body, rawQuery)
+	}
+	latency := time.Since(start)
+	deliveries.record(t, events, body, resp, err, latency)
+	history.RecordDelivery(events, resp, err, latency)
+	return resp, err
+}
+
+// sendUpstream posts an already translated body to the target.
+func sendUpstream(ctx context.Context, t target, body []byte, rawQuery string) (*upstreamResponse, error) {
 	// Create new request to destination
-	destURL := appendQuery(t.URL, r.URL.RawQuery)
+	destURL := appendQuery(t.URL, rawQuery)
+	if t.ThreadByCard && !strings.Contains(destURL, "messageReplyOption=") {
+		// Reply in the card's thread, or start it
+		destURL = appendQuery(destURL, "messageReplyOption=REPLY_MESSAGE_FALLBACK_TO_NEW_THREAD")
+	}
 
 	// Log the payload we are sending for debug
-	log.Printf("Forwarding to %s (%s): %s", t.Name, t.Type, string(newBody))
+	log.Printf("Forwarding to %s (%s): %s", t.Name, t.Type, string(body))
 
-	req, err := http.NewRequestWithContext(r.Context(), "POST", destURL, bytes.NewReader(newBody))
+	req, err := http.NewRequestWithContext(ctx, "POST", destURL, bytes.NewReader(body))
 	if err != nil {
-		http.Error(w, "failed to build forward request", http.StatusInternalServerError)
-		return
+		return nil, fmt.Errorf("build forward request: %w", err)
 	}
 
 	req.Header.Set("Content-Type", "application/json")
 	req.Header.Set("User-Agent", "Fizzy-Proxy/1.0")
 
-	client := &http.Client{Timeout: 10 * time.Second}
-	resp, err := client.Do(req)
+	resp, err := t.Client.Do(req)
 	if err != nil {
-		log.Printf("forward error (%s): %v", t.Name, err)
-		http.Error(w, "upstream error", http.StatusBadGateway)
-		return
+		return nil, err
 	}
 	defer resp.Body.Close()
 
-	// Read response body to log it (and then write it to w)
+	// Read response body to log it; it is never passed back to Fizzy
 	respBodyBytes, err := io.ReadAll(resp.Body)
 	if err != nil {
 		log.Printf("failed to read upstream response body: %v", err)
 	}
 	log.Printf("Upstream response (%s) Status: %d Body: %s", t.Name, resp.StatusCode, string(respBodyBytes))
 
-	for key, vals := range resp.Header {
-		for _, v := range vals {
-			w.Header().Add(key, v)
-		}
-	}
-	w.WriteHeader(resp.StatusCode)
-
-	if _, err := w.Write(respBodyBytes); err != nil {
-		log.Printf("response copy error (%s): %v", t.Name, err)
-	}
+	return &upstreamResponse{
+		StatusCode: resp.StatusCode,
+		Body:       respBodyBytes,
+	}, nil
 }
 
-// --- Translation Logic ---
-
-func translateToZulip(f FizzyPayload) ([]byte, error) {
-	msg := buildMessage(f)
-	payload := ZulipPayload{
-		Content: msg,
+// dispatch delivers an event in the background, honouring quiet hours and
+// the rate limit.
+func dispatch(t target, ev event) {
+	if t.Quiet != nil && t.Quiet.Hold(ev) {
+		return
 	}
-	return json.Marshal(payload)
-}
-
-func translateToGoogleChat(f FizzyPayload) ([]byte, error) {
-	actor := f.Creator.Name
-	if actor == "" {
-		actor = "Someone"
+	if t.Limiter != nil && !t.Limiter.Allow(ev) {
+		return
 	}
-	verb, emoji := prettyAction(f)
+	deliverBatch(t, []event{ev})
+}
 
-	finalURL := resolveFizzyURL(f)
+// Retries of queued deliveries that fail or are rejected with 429 or 5xx.
+const (
+	batchAttempts = 4
+	batchBackoff  = 2 * time.Second // Doubled after each attempt
+)
 
-	subjectTitle := f.Eventable.Title
-	if subjectTitle == "" {
-		if f.Board.Name != "" {
-			subjectTitle = f.Board.Name
-		} else {
-			subjectTitle = "Fizzy Notification"
+// deliverBatch sends queued events outside of a request, folding several
+// events into digest messages of at most maxDigestEvents each. The caller
+// has taken a rate limit token for the first digest; each further digest
+// waits for its own.
+func deliverBatch(t target, events []event) {
+	for first := true; len(events) > 0; first = false {
+		if !first && t.Limiter != nil {
+			t.Limiter.Wait()
 		}
+		n := len(events)
+		if n > maxDigestEvents {
+			n = maxDigestEvents
+		}
+		deliverChunk(t, events[:n])
+		events = events[n:]
 	}
+}
 
-	headerSubtitle := fmt.Sprintf("%s %s", actor, verb)
-
-	header := CardHeader{
-		Title:    subjectTitle,
-		Subtitle: headerSubtitle,
-		ImageURL: "",
-	}
-
-	var widgets []Widget
-
-	if f.Eventable.Body.PlainText != "" {
-		widgets = append(widgets, Widget{
-			TextParagraph: &TextParagraph{
-				Text: f.Eventable.Body.PlainText,
-			},
-		})
+// deliverChunk sends events as one message, retrying with backoff while the
+// target is unavailable or asks to slow down.
+func deliverChunk(t target, events []event) {
+	body, err := translateEvents(t, events)
+	if err != nil {
+		log.Printf("translation error for %s: %v", t.Name, err)
+		return
 	}
 
-	if f.Board.Name != "" && subjectTitle != f.Board.Name {
-		widgets = append(widgets, Widget{
-			DecoratedText: &DecoratedText{
-				TopLabel:  "Board",
-				Text:      f.Board.Name,
-				StartIcon: &Icon{KnownIcon: "TICKET"},
-			},
-		})
-	}
-
-	widgets = append(widgets, Widget{
-		ButtonList: &ButtonList{
-			Buttons: []Button{
-				{
-					Text: "View in Fizzy",
-					Icon: &Icon{KnownIcon: "OPEN_IN_NEW"},
-					OnClick: &OnClick{
-						OpenLink: &OpenLink{URL: finalURL},
-					},
-				},
-			},
-		},
-	})
+	backoff := batchBackoff
+	for attempt := 1; ; attempt++ {
+		resp, err := deliver(context.Background(), t, events, body)
+		reason := ""
+		switch {
+		case err != nil:
+			reason = err.Error()
+		case resp.StatusCode < 300:
+			return
+		case resp.StatusCode != http.StatusTooManyRequests && resp.StatusCode < 500:
+			log.Printf("batch delivery to %s failed with status %d (%d events)", t.Name, resp.StatusCode, len(events))
+			return
+		default:
+			reason = fmt.Sprintf("status %d", resp.StatusCode)
+		}
 
-	card := CardV2{
-		CardID: fmt.Sprintf("fizzy-%d", time.Now().UnixNano()),
-		Card: Card{
-			Header:   header,
-			Sections: []CardSection{{Widgets: widgets}},
-		},
+		if attempt == batchAttempts {
+			log.Printf("[WARN] Dropping %d events for %s after %d attempts: %s", len(events), t.Name, attempt, reason)
+			return
+		}
+		log.Printf("[WARN] Batch delivery to %s failed (%s), retrying in %s", t.Name, reason, backoff)
+		time.Sleep(backoff)
+		backoff *= 2
 	}
+}
 
-	fallbackText := fmt.Sprintf("%s %s %s: %s", emoji, actor, verb, subjectTitle)
+// --- Translation Logic ---
 
-	payload := GoogleChatPayload{
-		Text:    fallbackText,
-		CardsV2: []CardV2{card},
+func translateToZulip(cat *catalog, f FizzyPayload) ([]byte, error) {
+	msg := buildMessage(cat, f)
+	payload := ZulipPayload{
+		Content: msg,
 	}
 	return json.Marshal(payload)
 }
 
-func translateToGotify(f FizzyPayload) ([]byte, error) {
-	msg := buildMessage(f)
-	verb, _ := prettyAction(f)
-	actor := f.Creator.Name
-	if actor == "" {
-		actor = "Someone"
-	}
+func translateToGotify(cat *catalog, f FizzyPayload) ([]byte, error) {
+	return gotifyMessage(cat, f, buildMessage(cat, f))
+}
+
+// gotifyMessage wraps a markdown message for Gotify, titled after the event.
+func gotifyMessage(cat *catalog, f FizzyPayload, msg string) ([]byte, error) {
+	verb, _ := prettyAction(cat, f)
+	actor := actorName(cat, f)
 	title := fmt.Sprintf("Fizzy: %s %s", actor, verb)
 	payload := GotifyPayload{
 		Message:  msg,
@@ -564,15 +858,67 @@ func translateToGotify(f FizzyPayload) ([]byte, error) {
 }
 
 // buildMessage creates a human-readable string from the Fizzy payload.
-func buildMessage(f FizzyPayload) string {
-	actor := f.Creator.Name
-	if actor == "" {
-		actor = "Someone"
+func buildMessage(cat *catalog, f FizzyPayload) string {
+	actor := actorName(cat, f)
+
+	verb, emoji := prettyAction(cat, f)
+
+	subject := resolveSubject(cat, f)
+
+	// Body Content
+	var body string
+	if f.Eventable.Body.PlainText != "" {
+		body = fmt.Sprintf("> %s", f.Eventable.Body.PlainText)
 	}
 
-	verb, emoji := prettyAction(f)
+	// Extras (Board Name, etc.)
+	var extras []string
+	if f.Board.Name != "" && subject != f.Board.Name {
+		extras = append(extras, cat.text("board_line", f.Board.Name))
+	}
+
+	// Determine URL
+	urlStr := resolveFizzyURL(f)
+
+	var sb strings.Builder
 
-	// Determine Subject (Title)
+	hideSubject := false
+	if f.Action == "comment_created" && strings.HasPrefix(subject, cat.text("card_number", "")) {
+		hideSubject = true
+	}
+
+	if hideSubject {
+		sb.WriteString(fmt.Sprintf("### %s **%s** %s", emoji, actor, verb))
+	} else {
+		sb.WriteString(fmt.Sprintf("### %s **%s** %s: %s", emoji, actor, verb, subject))
+	}
+
+	if body != "" {
+		sb.WriteString("\n\n")
+		sb.WriteString(body)
+	}
+
+	if len(extras) > 0 {
+		sb.WriteString("\n\n")
+		sb.WriteString(strings.Join(extras, "\n"))
+	}
+
+	sb.WriteString(fmt.Sprintf("\n\n[%s](%s)", cat.text("view_in_fizzy"), urlStr))
+
+	return sb.String()
+}
+
+// actorName returns who triggered the event, falling back to "Someone".
+func actorName(cat *catalog, f FizzyPayload) string {
+	if f.Creator.Name != "" {
+		return f.Creator.Name
+	}
+	return cat.text("someone")
+}
+
+// resolveSubject picks the best human-readable subject for an event: the
+// card title when Fizzy sends one, otherwise the board or "Card #N".
+func resolveSubject(cat *catalog, f FizzyPayload) string {
 	subject := f.Eventable.Title
 	if subject == "" {
 		// Try to find title in other places (e.g. for comments)
@@ -585,26 +931,11 @@ func buildMessage(f FizzyPayload) string {
 		} else if f.Board.Name != "" {
 			subject = f.Board.Name
 		} else {
-			subject = "Fizzy Notification"
+			subject = cat.text("notification")
 		}
 	}
 
-	// Body Content
-	var body string
-	if f.Eventable.Body.PlainText != "" {
-		body = fmt.Sprintf("> %s", f.Eventable.Body.PlainText)
-	}
-
-	// Extras (Board Name, etc.)
-	var extras []string
-	if f.Board.Name != "" && subject != f.Board.Name {
-		extras = append(extras, fmt.Sprintf("Board: %s", f.Board.Name))
-	}
-
-	// Determine URL
-	urlStr := resolveFizzyURL(f)
-
-	if subject == f.Board.Name || subject == "Fizzy Notification" {
+	if subject == f.Board.Name || subject == cat.text("notification") {
 		// inspect raw URLs not the resolved one which might be a search URL
 		rawURL := f.Eventable.URL
 		if rawURL == "" {
@@ -630,38 +961,13 @@ func buildMessage(f FizzyPayload) string {
 					}
 				}
 				if idPart != "" {
-					subject = fmt.Sprintf("Card #%s", idPart)
+					subject = cat.text("card_number", idPart)
 				}
 			}
 		}
 	}
 
-	var sb strings.Builder
-
-	hideSubject := false
-	if f.Action == "comment_created" && strings.HasPrefix(subject, "Card #") {
-		hideSubject = true
-	}
-
-	if hideSubject {
-		sb.WriteString(fmt.Sprintf("### %s **%s** %s", emoji, actor, verb))
-	} else {
-		sb.WriteString(fmt.Sprintf("### %s **%s** %s: %s", emoji, actor, verb, subject))
-	}
-
-	if body != "" {
-		sb.WriteString("\n\n")
-		sb.WriteString(body)
-	}
-
-	if len(extras) > 0 {
-		sb.WriteString("\n\n")
-		sb.WriteString(strings.Join(extras, "\n"))
-	}
-
-	sb.WriteString(fmt.Sprintf("\n\n[View in Fizzy](%s)", urlStr))
-
-	return sb.String()
+	return subject
 }
 
 func resolveFizzyURL(f FizzyPayload) string {
@@ -705,17 +1011,7 @@ func resolveFizzyURL(f FizzyPayload) string {
 	}
 
 	// 2. Fallback for Comments (Search Strategy)
-	cardUUID := ""
-	if strings.Contains(urlStr, "/cards/") {
-		parts := strings.Split(urlStr, "/cards/")
-		if len(parts) > 1 {
-			sub := parts[1]
-			slashParts := strings.Split(sub, "/")
-			if len(slashParts) > 0 {
-				cardUUID = slashParts[0]
-			}
-		}
-	}
+	cardUUID := extractCardRef(urlStr)
 
 	if cardUUID != "" {
 		slug := "0000001"
@@ -793,60 +1089,109 @@ func resolveFizzyURL(f FizzyPayload) string {
 	return urlStr
 }
 
-func prettyAction(f FizzyPayload) (verb string, emoji string) {
-	action := f.Action
-	// Normalize action string just in case
-	action = strings.ToLower(action)
+// extractCardRef returns the path segment following "/cards/" in a Fizzy
+// URL, which is either the card number or the card UUID.
+func extractCardRef(urlStr string) string {
+	if !strings.Contains(urlStr, "/cards/") {
+		return ""
+	}
+	parts := strings.Split(urlStr, "/cards/")
+	if len(parts) < 2 {
+		return ""
+	}
+	ref := strings.Split(parts[1], "/")[0]
+	if i := strings.IndexAny(ref, "?#"); i >= 0 {
+		ref = ref[:i]
+	}
+	return ref
+}
+
+// cardKey identifies the card an event belongs to, so events on the same card
+// can be grouped. Card events carry the card's ID, which comment events only
+// have in their URL, so the ID is preferred over the number to give both the
+// same key. Returns empty string when the card cannot be determined.
+func cardKey(f FizzyPayload) string {
+	if strings.HasPrefix(strings.ToLower(f.Action), "card_") && f.Eventable.ID != "" {
+		return "card:" + f.Eventable.ID
+	}
+	for _, u := range []string{f.Eventable.URL, f.URL, f.Eventable.ReactionsURL} {
+		if ref := extractCardRef(u); ref != "" {
+			return "card:" + ref
+		}
+	}
+	if f.Eventable.Number != 0 {
+		return fmt.Sprintf("card:%d", f.Eventable.Number)
+	}
+	return ""
+}
+
+// isCard reports whether ref, a card number or card ID, names the card of f.
+func isCard(f FizzyPayload, ref string) bool {
+	if cardKey(f) == "card:"+ref {
+		return true
+	}
+	return strings.HasPrefix(strings.ToLower(f.Action), "card_") && f.Eventable.Number != 0 && fmt.Sprint(f.Eventable.Number) == ref
+}
+
+// knownActions lists the Fizzy actions prettyAction has wording for.
+var knownActions = []string{
+	"card_created",
+	"card_published",
+	"comment_created",
+	"card_assigned",
+	"card_unassigned",
+	"card_moved",
+	"card_board_changed",
+	"card_postponed",
+	"card_reopened",
+	"card_sent_back_to_triage",
+	"card_closed",
+	"card_archived",
+}
+
+// prettyAction describes the event in the catalog's language, with an emoji.
+func prettyAction(cat *catalog, f FizzyPayload) (verb string, emoji string) {
+	action := strings.ToLower(f.Action)
+	key, args := actionKey(cat, action, f)
+	return cat.verb(key, args...), cat.emoji(key, action)
+}
+
+// actionKey picks the catalog entry for an event. Most actions use their own
+// name; a few read differently depending on the column, the reason or the
+// assignee, e.g. card_closed in a completed column is "card_completed".
+func actionKey(cat *catalog, action string, f FizzyPayload) (string, []interface{}) {
+	column := ""
+	if f.Column != nil {
+		column = f.Column.Name
+	}
 
 	switch action {
-	case "comment_created":
-		return "commented", "💬"
-	case "card_created":
-		return "created a card", "🃏"
-	case "card_published":
-		return "published a card", "📢"
-	case "card_reopened":
-		return "reopened the card", "🔄"
-	case "card_board_changed":
-		return "changed the card's board", "📋"
 	case "card_moved":
-		if f.Column != nil && f.Column.Name != "" {
-			if f.Reason == "inactivity" {
-				return fmt.Sprintf("moved the card to **%s** due to inactivity", f.Column.Name), "💤"
-			}
-			return fmt.Sprintf("moved the card to **%s**", f.Column.Name), "🚚"
+		if column == "" {
+			break
+		}
+		// A reason such as "inactivity" has its own wording when the
+		// catalog defines card_moved_{reason}.
+		if reason := strings.ToLower(f.Reason); reason != "" && cat.hasVerb("card_moved_"+reason) {
+			return "card_moved_" + reason, []interface{}{column}
 		}
-		return "moved the card", "🚚"
+		return "card_moved_to", []interface{}{column}
 	case "card_assigned":
 		if f.Assignee != nil && f.Assignee.Name != "" {
-			return fmt.Sprintf("assigned the card to **%s**", f.Assignee.Name), "👤"
-		}
-		return "assigned the card to someone", "👤"
-	case "card_unassigned":
-		return "unassigned the card", "👤"
-	case "card_postponed":
-		return "postponed the card", "💤"
-	case "card_closed":
-		if f.Column != nil && strings.EqualFold(f.Column.Name, "Done") {
-			return "completed the card", "✅"
-		}
-		return "closed the card", "✅"
-	case "card_sent_back_to_triage":
-		return "sent the card back to triage", "↩️"
-	case "card_archived":
-		// Check for "Done" or "Postponed" if possible...
-		if f.Column != nil {
-			if strings.EqualFold(f.Column.Name, "Done") {
-				return "completed the card", "✅"
-			}
-			if strings.EqualFold(f.Column.Name, "Postponed") || strings.EqualFold(f.Column.Name, "Not Now") {
-				return "postponed the card", "😴"
+			if m, ok := cat.mention(f.Assignee.Name); ok {
+				return "card_assigned_mention", []interface{}{m}
 			}
+			return "card_assigned_to", []interface{}{f.Assignee.Name}
+		}
+	case "card_closed", "card_archived":
+		if cat.isCompleted(column) {
+			return "card_completed", nil
+		}
+		if action == "card_archived" && cat.isPostponed(column) {
+			return "card_archived_postponed", nil
 		}
-		return "archived the card", "📦"
-	default:
-		return strings.ReplaceAll(action, "_", " "), "📢"
 	}
+	return action, nil
 }
 
 // --- Helpers ---
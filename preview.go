@@ -0,0 +1,136 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+)
+
+// --- Translation Preview ---
+
+// previewSuffix is appended to a target's path to render without sending.
+const previewSuffix = "/preview"
+
+// errPreviewPayload marks a preview body that is not a Fizzy payload, as
+// opposed to a payload the target's formatting or template fails on.
+var errPreviewPayload = errors.New("invalid fizzy json")
+
+// renderPreview translates a raw Fizzy payload for t without sending it.
+func renderPreview(t target, body []byte) ([]byte, error) {
+	var fizzy FizzyPayload
+	if err := json.Unmarshal(body, &fizzy); err != nil {
+		return nil, fmt.Errorf("%w: %v", errPreviewPayload, err)
+	}
+	return translate(t, event{Payload: fizzy, Raw: body, Received: time.Now()})
+}
+
+// previewRequest handles POST /{TOKEN}/{identifier}/preview and answers with
+// the message that would be sent to the target.
+func previewRequest(w http.ResponseWriter, r *http.Request, t target) {
+	if !requirePost(w, r) {
+		return
+	}
+
+	body, ok := readWebhookBody(w, r)
+	if !ok {
+		return
+	}
+
+	// Unlike webhook errors, preview errors carry their details: the endpoint
+	// is authenticated and exists to debug formatting and templates.
+	rendered, err := renderPreview(t, body)
+	if errors.Is(err, errPreviewPayload) {
+		log.Printf("preview %s for %s failed: %v", correlationID(r), t.Name, err)
+		writeWebhookError(w, r, http.StatusBadRequest, codeInvalidPayload, "request body is not a valid Fizzy webhook payload: "+err.Error())
+		return
+	}
+	if err != nil {
+		log.Printf("preview %s for %s failed: %v", correlationID(r), t.Name, err)
+		writeWebhookError(w, r, http.StatusUnprocessableEntity, codeTranslationFailed, err.Error())
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	w.Write(indentJSON(rendered))
+}
+
+// runPreview implements "fizzy-webhook-proxy preview --type TYPE FILE". The
+// payload is read from FILE, or from stdin when FILE is "-" or missing.
+func runPreview(args []string) int {
+	fs := flag.NewFlagSet("preview", flag.ContinueOnError)
+	targetType := fs.String("type", "", "target type: zulip, google-chat or gotify")
+	templateFile := fs.String("template", "", "message template file to try instead of the built-in formatting")
+	locale := fs.String("locale", "", "message language, e.g. tr (default LOCALE or en)")
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "usage: fizzy-webhook-proxy preview --type TYPE [--template FILE] [--locale LOCALE] [payload.json]")
+		fs.PrintDefaults()
+	}
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+
+	t := target{Name: "preview", Type: TargetType(strings.ToLower(*targetType))}
+	if !knownTargetType(t.Type) {
+		fmt.Fprintf(os.Stderr, "unknown or missing --type %q\n", *targetType)
+		fs.Usage()
+		return 2
+	}
+
+	cfg := targetConfig{Identifier: "preview", TemplateFile: *templateFile, Locale: *locale}
+	var err error
+	if t.Catalog, err = loadCatalog(cfg); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 2
+	}
+	if err = loadUserDirectory(); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+	t.Catalog = t.Catalog.withMentions(users.mentions(t.Type))
+	if t.CompactCards, err = loadCardStyle(cfg); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 2
+	}
+	if t.Template, err = loadTemplate(cfg, t.Catalog); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+
+	var body []byte
+	if path := fs.Arg(0); path != "" && path != "-" {
+		body, err = os.ReadFile(path)
+	} else {
+		body, err = io.ReadAll(os.Stdin)
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "read payload: %v\n", err)
+		return 1
+	}
+
+	rendered, err := renderPreview(t, body)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+	os.Stdout.Write(indentJSON(rendered))
+	return 0
+}
+
+// indentJSON pretty-prints JSON for humans, returning other data unchanged.
+func indentJSON(data []byte) []byte {
+	var buf bytes.Buffer
+	if err := json.Indent(&buf, data, "", "  "); err != nil {
+		return data
+	}
+	buf.WriteByte('\n')
+	return buf.Bytes()
+}
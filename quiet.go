@@ -0,0 +1,189 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+	_ "time/tzdata" // Time zones must work on minimal hosts without zoneinfo
+)
+
+// --- Quiet Hours ---
+
+// quietHours holds back non-urgent events during a daily window (e.g.
+// 22:00-07:00 in the target's time zone) and delivers them as one batch
+// when the window closes.
+type quietHours struct {
+	mu     sync.Mutex
+	start  int // Minutes after midnight
+	end    int // Minutes after midnight; equal to start means the whole day
+	days   [7]bool
+	loc    *time.Location
+	urgent map[string]bool
+	held   []event
+	timer  *time.Timer
+	flush  func([]event)
+}
+
+// Hold queues ev if the target is currently in quiet hours and the action is
+// not urgent. It returns false when the event should be delivered now.
+func (q *quietHours) Hold(ev event) bool {
+	if q.urgent[strings.ToLower(ev.Payload.Action)] {
+		return false
+	}
+
+	now := time.Now()
+	if !q.active(now) {
+		return false
+	}
+
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	if len(q.held) >= maxQueuedEvents {
+		log.Printf("warning: quiet hours queue full, dropping oldest event (%s)", q.held[0].Payload.Action)
+		q.held = q.held[1:]
+	}
+	q.held = append(q.held, ev)
+
+	if q.timer == nil {
+		q.timer = time.AfterFunc(time.Until(q.windowEnd(now)), q.release)
+	}
+	return true
+}
+
+// Delay returns how long a message about ev has to wait for quiet hours to
+// end, or zero when it may be sent now. Direct messages are held this way.
+func (q *quietHours) Delay(ev event) time.Duration {
+	if q.urgent[strings.ToLower(ev.Payload.Action)] {
+		return 0
+	}
+	now := time.Now()
+	if !q.active(now) {
+		return 0
+	}
+	return q.windowEnd(now).Sub(now)
+}
+
+func (q *quietHours) release() {
+	q.mu.Lock()
+	events := q.held
+	q.held = nil
+	q.timer = nil
+	q.mu.Unlock()
+
+	q.flush(events)
+}
+
+// active reports whether t falls inside the quiet window. A window that
+// crosses midnight belongs to the day on which it starts.
+func (q *quietHours) active(t time.Time) bool {
+	t = t.In(q.loc)
+	m := t.Hour()*60 + t.Minute()
+	day := t.Weekday()
+	prev := (day + 6) % 7
+
+	switch {
+	case q.start == q.end:
+		return q.days[day]
+	case q.start < q.end:
+		return q.days[day] && m >= q.start && m < q.end
+	default:
+		return (q.days[day] && m >= q.start) || (q.days[prev] && m < q.end)
+	}
+}
+
+// windowEnd returns the first minute after t that is outside quiet hours.
+func (q *quietHours) windowEnd(t time.Time) time.Time {
+	next := t.Truncate(time.Minute)
+	// A full week of quiet days is the longest possible window.
+	for i := 0; i < 8*24*60; i++ {
+		next = next.Add(time.Minute)
+		if !q.active(next) {
+			return next
+		}
+	}
+	return next
+}
+
+// loadLocation returns the time zone of a target, falling back to the global
+// TIMEZONE and then the server's local zone.
+func loadLocation(tc targetConfig) (*time.Location, error) {
+	name := tc.Timezone
+	if name == "" {
+		name = os.Getenv("TIMEZONE")
+	}
+	if name == "" {
+		return time.Local, nil
+	}
+	return time.LoadLocation(name)
+}
+
+// loadQuietHours builds the quiet window of a target. It returns nil when no
+// window is configured.
+func loadQuietHours(tc targetConfig, loc *time.Location, flush func([]event)) (*quietHours, error) {
+	window := tc.QuietHours
+	if window == "" {
+		return nil, nil
+	}
+
+	from, to, ok := strings.Cut(window, "-")
+	if !ok {
+		return nil, fmt.Errorf("quiet hours %q must look like 22:00-07:00", window)
+	}
+	start, err := parseClock(from)
+	if err != nil {
+		return nil, err
+	}
+	end, err := parseClock(to)
+	if err != nil {
+		return nil, err
+	}
+
+	q := &quietHours{
+		start:  start,
+		end:    end,
+		loc:    loc,
+		urgent: make(map[string]bool),
+		flush:  flush,
+	}
+
+	days := tc.QuietDays
+	if days == "" {
+		days = "sun-sat"
+	}
+	var set [8]bool
+	if err := parseCronField(strings.ReplaceAll(days, " ", ""), 0, 7, weekdayNames, set[:]); err != nil {
+		return nil, fmt.Errorf("quiet days: %w", err)
+	}
+	copy(q.days[:], set[:7])
+	if set[7] {
+		q.days[0] = true
+	}
+
+	for _, action := range tc.UrgentActions {
+		q.urgent[strings.ToLower(action)] = true
+	}
+
+	return q, nil
+}
+
+// parseClock parses "HH:MM" into minutes after midnight.
+func parseClock(s string) (int, error) {
+	hh, mm, ok := strings.Cut(strings.TrimSpace(s), ":")
+	if !ok {
+		return 0, fmt.Errorf("invalid time %q (use HH:MM)", s)
+	}
+	h, err := strconv.Atoi(hh)
+	if err != nil || h < 0 || h > 23 {
+		return 0, fmt.Errorf("invalid time %q (use HH:MM)", s)
+	}
+	m, err := strconv.Atoi(mm)
+	if err != nil || m < 0 || m > 59 {
+		return 0, fmt.Errorf("invalid time %q (use HH:MM)", s)
+	}
+	return h*60 + m, nil
+}
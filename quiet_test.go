@@ -0,0 +1,137 @@
+package main
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestQuietHoursActive(t *testing.T) {
+	// 2026-10-19 is a Monday, 2026-10-23 a Friday.
+	tests := []struct {
+		window string
+		days   string
+		at     string
+		want   bool
+	}{
+		{"22:00-07:00", "", "2026-10-19 21:59", false},
+		{"22:00-07:00", "", "2026-10-19 22:00", true},
+		{"22:00-07:00", "", "2026-10-20 03:00", true},
+		{"22:00-07:00", "", "2026-10-20 06:59", true},
+		{"22:00-07:00", "", "2026-10-20 07:00", false},
+		{"12:00-13:00", "", "2026-10-19 12:30", true},
+		{"12:00-13:00", "", "2026-10-19 13:00", false},
+
+		// A window crossing midnight belongs to the day it starts on.
+		{"22:00-07:00", "mon-fri", "2026-10-23 23:00", true},  // Friday night
+		{"22:00-07:00", "mon-fri", "2026-10-24 03:00", true},  // Early Saturday, from Friday
+		{"22:00-07:00", "mon-fri", "2026-10-24 23:00", false}, // Saturday night
+		{"22:00-07:00", "mon-fri", "2026-10-26 03:00", false}, // Early Monday, from Sunday
+		{"22:00-07:00", "mon-fri", "2026-10-26 23:00", true},  // Monday night
+
+		// Equal start and end means the whole day.
+		{"00:00-00:00", "sat,sun", "2026-10-24 10:00", true},
+		{"00:00-00:00", "sat,sun", "2026-10-26 10:00", false},
+		{"00:00-00:00", "6-7", "2026-10-25 23:59", true},
+	}
+
+	for _, tt := range tests {
+		q := mustQuietHours(t, tt.window, tt.days)
+		at := mustTime(t, tt.at)
+		if got := q.active(at); got != tt.want {
+			t.Errorf("%s (%s) active at %s = %v, want %v", tt.window, tt.days, tt.at, got, tt.want)
+		}
+	}
+}
+
+func TestQuietHoursWindowEnd(t *testing.T) {
+	tests := []struct {
+		window string
+		days   string
+		at     string
+		want   string
+	}{
+		{"22:00-07:00", "", "2026-10-19 23:30", "2026-10-20 07:00"},
+		{"22:00-07:00", "", "2026-10-20 02:15", "2026-10-20 07:00"},
+		{"22:00-07:00", "mon-fri", "2026-10-24 03:00", "2026-10-24 07:00"},
+		{"00:00-00:00", "sat,sun", "2026-10-24 10:00", "2026-10-26 00:00"},
+		{"18:00-09:00", "fri,sat,sun", "2026-10-23 19:00", "2026-10-24 09:00"},
+	}
+
+	for _, tt := range tests {
+		q := mustQuietHours(t, tt.window, tt.days)
+		if got := q.windowEnd(mustTime(t, tt.at)); !got.Equal(mustTime(t, tt.want)) {
+			t.Errorf("%s (%s) window end from %s = %s, want %s", tt.window, tt.days, tt.at, got.Format("2006-01-02 15:04"), tt.want)
+		}
+	}
+}
+
+func TestQuietHoursInLocation(t *testing.T) {
+	loc, err := time.LoadLocation("America/New_York")
+	if err != nil {
+		t.Fatal(err)
+	}
+	q, err := loadQuietHours(targetConfig{QuietHours: "22:00-07:00"}, loc, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// 03:00 UTC is 23:00 the evening before in New York.
+	if !q.active(time.Date(2026, 10, 20, 3, 0, 0, 0, time.UTC)) {
+		t.Error("03:00 UTC should be quiet in New York")
+	}
+	// 12:00 UTC is 08:00 in New York.
+	if q.active(time.Date(2026, 10, 20, 12, 0, 0, 0, time.UTC)) {
+		t.Error("12:00 UTC should not be quiet in New York")
+	}
+}
+
+func TestQuietHoursUrgent(t *testing.T) {
+	// Quiet all of today, so the window ends at the next midnight.
+	today := strings.ToLower(time.Now().UTC().Weekday().String()[:3])
+	q, err := loadQuietHours(targetConfig{QuietHours: "00:00-00:00", QuietDays: today, UrgentActions: []string{"Card_Assigned"}}, time.UTC, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	urgent := event{Payload: FizzyPayload{Action: "card_assigned"}}
+	if q.Hold(urgent) || q.Delay(urgent) != 0 {
+		t.Error("urgent action should not be held")
+	}
+	if d := q.Delay(event{Payload: FizzyPayload{Action: "card_moved"}}); d <= 0 || d > 24*time.Hour {
+		t.Errorf("Delay for a quiet action = %s, want up to a day", d)
+	}
+}
+
+func TestLoadQuietHoursErrors(t *testing.T) {
+	for _, tc := range []targetConfig{
+		{QuietHours: "22:00"},
+		{QuietHours: "25:00-07:00"},
+		{QuietHours: "22:00-07:60"},
+		{QuietHours: "22-07"},
+		{QuietHours: "22:00-07:00", QuietDays: "weekdays"},
+		{QuietHours: "22:00-07:00", QuietDays: "fri-mon"},
+	} {
+		if _, err := loadQuietHours(tc, time.UTC, nil); err == nil {
+			t.Errorf("loadQuietHours(%q, %q) = nil error, want error", tc.QuietHours, tc.QuietDays)
+		}
+	}
+}
+
+func mustQuietHours(t *testing.T, window, days string) *quietHours {
+	t.Helper()
+	q, err := loadQuietHours(targetConfig{QuietHours: window, QuietDays: days}, time.UTC, nil)
+	if err != nil {
+		t.Fatalf("loadQuietHours(%q, %q): %v", window, days, err)
+	}
+	return q
+}
+
+func mustTime(t *testing.T, s string) time.Time {
+	t.Helper()
+	ts, err := time.Parse("2006-01-02 15:04", s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return ts
+}
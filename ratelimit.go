@@ -0,0 +1,162 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"math"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// --- Rate Limiting ---
+
+// maxQueuedEvents bounds how many events a rate limited target keeps in
+// memory. Older events are dropped first once the limit is reached.
+const maxQueuedEvents = 500
+
+// rateLimiter is a token bucket guarding a single target. Events that arrive
+// while the bucket is empty are queued and flushed together as soon as a
+// token becomes available, so a burst of events becomes one digest message.
+type rateLimiter struct {
+	mu     sync.Mutex
+	rate   float64 // Tokens added per second
+	burst  float64 // Bucket capacity
+	tokens float64
+	last   time.Time
+	queue  []event
+	timer  *time.Timer
+	flush  func([]event)
+}
+
+func newRateLimiter(rate float64, burst int, flush func([]event)) *rateLimiter {
+	return &rateLimiter{
+		rate:   rate,
+		burst:  float64(burst),
+		tokens: float64(burst),
+		last:   time.Now(),
+		flush:  flush,
+	}
+}
+
+// Allow reports whether ev may be delivered right away. When it returns
+// false the event has been queued and will be handed to flush later.
+func (l *rateLimiter) Allow(ev event) bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.refill()
+	if len(l.queue) == 0 && l.tokens >= 1 {
+		l.tokens--
+		return true
+	}
+
+	if len(l.queue) >= maxQueuedEvents {
+		log.Printf("warning: rate limit queue full, dropping oldest event (%s)", l.queue[0].Payload.Action)
+		l.queue = l.queue[1:]
+	}
+	l.queue = append(l.queue, ev)
+	l.schedule()
+	return false
+}
+
+// Wait blocks until a token is available and takes it. deliverBatch uses it
+// to space out the digests of a queue too large for one message.
+func (l *rateLimiter) Wait() {
+	for {
+		l.mu.Lock()
+		l.refill()
+		if l.tokens >= 1 {
+			l.tokens--
+			l.mu.Unlock()
+			return
+		}
+		wait := time.Duration((1 - l.tokens) / l.rate * float64(time.Second))
+		l.mu.Unlock()
+		time.Sleep(wait)
+	}
+}
+
+// refill adds the tokens earned since the last call. Callers hold l.mu.
+func (l *rateLimiter) refill() {
+	now := time.Now()
+	l.tokens = math.Min(l.burst, l.tokens+now.Sub(l.last).Seconds()*l.rate)
+	l.last = now
+}
+
+// schedule arms the flush timer for the moment the next token is available.
+// Callers hold l.mu.
+func (l *rateLimiter) schedule() {
+	if l.timer != nil {
+		return
+	}
+	wait := time.Duration((1 - l.tokens) / l.rate * float64(time.Second))
+	if wait < 0 {
+		wait = 0
+	}
+	l.timer = time.AfterFunc(wait, l.onTimer)
+}
+
+func (l *rateLimiter) onTimer() {
+	l.mu.Lock()
+	l.timer = nil
+	l.refill()
+	if l.tokens < 1 {
+		// Timer fired a little early; try again once the token is there.
+		l.schedule()
+		l.mu.Unlock()
+		return
+	}
+	l.tokens--
+	events := l.queue
+	l.queue = nil
+	l.mu.Unlock()
+
+	l.flush(events)
+}
+
+// parseRate parses a rate such as "30/m", "2/s" or "100/h" into events per
+// second. A bare number is read as events per second.
+func parseRate(s string) (float64, error) {
+	count, unit, found := strings.Cut(strings.TrimSpace(s), "/")
+	n, err := strconv.ParseFloat(strings.TrimSpace(count), 64)
+	if err != nil || n <= 0 {
+		return 0, fmt.Errorf("invalid rate %q", s)
+	}
+	if !found {
+		return n, nil
+	}
+
+	switch strings.ToLower(strings.TrimSpace(unit)) {
+	case "s", "sec", "second":
+		return n, nil
+	case "m", "min", "minute":
+		return n / 60, nil
+	case "h", "hour":
+		return n / 3600, nil
+	default:
+		return 0, fmt.Errorf("invalid rate unit in %q (use s, m or h)", s)
+	}
+}
+
+// loadRateLimiter builds the limiter for a target from its rate limit and
+// burst settings. It returns nil when no limit is configured.
+func loadRateLimiter(tc targetConfig, flush func([]event)) (*rateLimiter, error) {
+	if tc.RateLimit == "" {
+		return nil, nil
+	}
+	rate, err := parseRate(tc.RateLimit)
+	if err != nil {
+		return nil, err
+	}
+
+	burst := 1
+	if tc.RateBurst < 0 {
+		return nil, fmt.Errorf("invalid burst %d", tc.RateBurst)
+	} else if tc.RateBurst > 0 {
+		burst = tc.RateBurst
+	}
+
+	return newRateLimiter(rate, burst, flush), nil
+}
@@ -0,0 +1,26 @@
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+func TestRateLimiterWait(t *testing.T) {
+	l := newRateLimiter(20, 1, nil) // One token every 50ms
+
+	start := time.Now()
+	l.Wait() // Takes the burst token
+	l.Wait()
+	l.Wait()
+	if elapsed := time.Since(start); elapsed < 90*time.Millisecond {
+		t.Errorf("three waits took %s, want about 100ms", elapsed)
+	}
+
+	// A waiter that took the token leaves none for new events.
+	if l.Allow(event{}) {
+		t.Error("Allow succeeded right after Wait took the token")
+	}
+	l.mu.Lock()
+	l.timer.Stop()
+	l.mu.Unlock()
+}
@@ -0,0 +1,81 @@
+package main
+
+import (
+	"sort"
+	"sync"
+)
+
+// --- Target Registry ---
+
+// registry holds the live set of targets. Requests look targets up by path
+// on every call, so targets can be added, changed or removed at runtime
+// without re-creating the HTTP mux.
+type registry struct {
+	mu      sync.RWMutex
+	targets map[string]*target // By identifier
+}
+
+func newRegistry() *registry {
+	return &registry{targets: make(map[string]*target)}
+}
+
+// list returns all targets sorted by identifier.
+func (reg *registry) list() []*target {
+	reg.mu.RLock()
+	defer reg.mu.RUnlock()
+
+	targets := make([]*target, 0, len(reg.targets))
+	for _, t := range reg.targets {
+		targets = append(targets, t)
+	}
+	sort.Slice(targets, func(i, j int) bool { return targets[i].Identifier < targets[j].Identifier })
+	return targets
+}
+
+func (reg *registry) get(identifier string) *target {
+	reg.mu.RLock()
+	defer reg.mu.RUnlock()
+	return reg.targets[identifier]
+}
+
+// put registers t, replacing and stopping any target with the same
+// identifier. Events already queued by the old target are still delivered.
+func (reg *registry) put(t *target) {
+	reg.mu.Lock()
+	old := reg.targets[t.Identifier]
+	reg.targets[t.Identifier] = t
+	reg.mu.Unlock()
+
+	if old != nil {
+		old.stop()
+	}
+	t.start()
+}
+
+// remove unregisters and stops the target with the given identifier.
+func (reg *registry) remove(identifier string) bool {
+	reg.mu.Lock()
+	old := reg.targets[identifier]
+	delete(reg.targets, identifier)
+	reg.mu.Unlock()
+
+	if old == nil {
+		return false
+	}
+	old.stop()
+	return true
+}
+
+// start launches the background jobs of a target.
+func (t *target) start() {
+	if t.Summary != nil && !t.Disabled {
+		go t.Summary.run(*t)
+	}
+}
+
+// stop ends the background jobs of a target.
+func (t *target) stop() {
+	if t.Summary != nil {
+		t.Summary.Stop()
+	}
+}
@@ -0,0 +1,71 @@
+package main
+
+import (
+	"context"
+	"net/http"
+)
+
+// --- Webhook Responses ---
+
+// Error codes returned to webhook callers. They are part of the API and must
+// not change; details go to the log under the correlation ID instead.
+const (
+	codeMethodNotAllowed    = "method_not_allowed"
+	codePayloadTooLarge     = "payload_too_large"
+	codeUnreadableBody      = "unreadable_body"
+	codeInvalidPayload      = "invalid_payload"
+	codeUnauthorized        = "unauthorized"
+	codeForbidden           = "forbidden"
+	codeTargetUnavailable   = "target_unavailable"
+	codeTranslationFailed   = "translation_failed"
+	codeUpstreamUnreachable = "upstream_unreachable"
+	codeUpstreamRejected    = "upstream_rejected"
+)
+
+// correlationHeader carries the correlation ID of a webhook request. The ID
+// is also the history ID of the event, and appears in every log line about
+// the request.
+const correlationHeader = "X-Correlation-ID"
+
+type webhookError struct {
+	Code          string `json:"code"`
+	Message       string `json:"message"`
+	CorrelationID string `json:"correlation_id"`
+}
+
+type webhookStatus struct {
+	Status        string `json:"status"` // Outcome, e.g. "sent" or "held_for_quiet_hours"
+	CorrelationID string `json:"correlation_id"`
+}
+
+type correlationKey struct{}
+
+// withCorrelationID assigns a new correlation ID to a webhook request and
+// announces it in the response headers.
+func withCorrelationID(w http.ResponseWriter, r *http.Request) *http.Request {
+	id := newEventID()
+	w.Header().Set(correlationHeader, id)
+	return r.WithContext(context.WithValue(r.Context(), correlationKey{}, id))
+}
+
+// correlationID returns the ID assigned by withCorrelationID, or a new one.
+func correlationID(r *http.Request) string {
+	if id, ok := r.Context().Value(correlationKey{}).(string); ok {
+		return id
+	}
+	return newEventID()
+}
+
+// writeWebhookError answers a webhook request with the error envelope:
+//
+//	{"error": {"code": "...", "message": "...", "correlation_id": "..."}}
+func writeWebhookError(w http.ResponseWriter, r *http.Request, status int, code, message string) {
+	writeJSON(w, status, map[string]webhookError{
+		"error": {Code: code, Message: message, CorrelationID: correlationID(r)},
+	})
+}
+
+// writeWebhookStatus acknowledges a webhook request that was handled.
+func writeWebhookStatus(w http.ResponseWriter, r *http.Request, status int, outcome string) {
+	writeJSON(w, status, webhookStatus{Status: outcome, CorrelationID: correlationID(r)})
+}
@@ -0,0 +1,141 @@
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseCronField(t *testing.T) {
+	tests := []struct {
+		field    string
+		min, max int
+		names    map[string]int
+		want     []int
+		wantErr  bool
+	}{
+		{field: "*", min: 0, max: 6, want: []int{0, 1, 2, 3, 4, 5, 6}},
+		{field: "5", min: 0, max: 59, want: []int{5}},
+		{field: "1,3,5", min: 0, max: 6, want: []int{1, 3, 5}},
+		{field: "1-4", min: 0, max: 6, want: []int{1, 2, 3, 4}},
+		{field: "*/15", min: 0, max: 59, want: []int{0, 15, 30, 45}},
+		{field: "8-18/4", min: 0, max: 23, want: []int{8, 12, 16}},
+		{field: "10/20", min: 0, max: 59, want: []int{10, 30, 50}},
+		{field: "mon-fri", min: 0, max: 7, names: weekdayNames, want: []int{1, 2, 3, 4, 5}},
+		{field: "SAT,sun", min: 0, max: 7, names: weekdayNames, want: []int{0, 6}},
+		{field: "60", min: 0, max: 59, wantErr: true},
+		{field: "0", min: 1, max: 31, wantErr: true},
+		{field: "5-1", min: 0, max: 59, wantErr: true},
+		{field: "*/0", min: 0, max: 59, wantErr: true},
+		{field: "*/x", min: 0, max: 59, wantErr: true},
+		{field: "funday", min: 0, max: 7, names: weekdayNames, wantErr: true},
+		{field: "", min: 0, max: 59, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		set := make([]bool, tt.max+1)
+		err := parseCronField(tt.field, tt.min, tt.max, tt.names, set)
+		if tt.wantErr {
+			if err == nil {
+				t.Errorf("parseCronField(%q) = nil error, want error", tt.field)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("parseCronField(%q) error: %v", tt.field, err)
+			continue
+		}
+
+		var got []int
+		for i, ok := range set {
+			if ok {
+				got = append(got, i)
+			}
+		}
+		if !equalInts(got, tt.want) {
+			t.Errorf("parseCronField(%q) = %v, want %v", tt.field, got, tt.want)
+		}
+	}
+}
+
+func TestParseCronErrors(t *testing.T) {
+	for _, expr := range []string{"", "* * * *", "* * * * * *", "61 * * * *", "* 24 * * *", "* * 32 * *", "* * * 13 *", "* * * * 8"} {
+		if _, err := parseCron(expr); err == nil {
+			t.Errorf("parseCron(%q) = nil error, want error", expr)
+		}
+	}
+}
+
+func TestCronNext(t *testing.T) {
+	utc := func(s string) time.Time {
+		ts, err := time.Parse("2006-01-02 15:04", s)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return ts
+	}
+
+	// 2026-10-19 is a Monday.
+	tests := []struct {
+		expr string
+		from string
+		want string
+	}{
+		{"0 9 * * *", "2026-10-19 08:59", "2026-10-19 09:00"},
+		{"0 9 * * *", "2026-10-19 09:00", "2026-10-20 09:00"},
+		{"*/15 * * * *", "2026-10-19 10:07", "2026-10-19 10:15"},
+		{"*/30 8-18 * * *", "2026-10-19 18:45", "2026-10-20 08:00"},
+		{"0 9 * * 1-5", "2026-10-23 10:00", "2026-10-26 09:00"},
+		{"0 9 * * sat,sun", "2026-10-19 10:00", "2026-10-24 09:00"},
+		{"0 9 * * 7", "2026-10-19 10:00", "2026-10-25 09:00"},
+		{"0 0 1 * *", "2026-12-15 00:00", "2027-01-01 00:00"},
+		{"0 0 29 2 *", "2026-03-01 00:00", "2028-02-29 00:00"},
+		// Both day fields restricted: either one matches.
+		{"0 9 1 * mon", "2026-10-20 00:00", "2026-10-26 09:00"},
+		{"0 9 1 * mon", "2026-10-27 00:00", "2026-11-01 09:00"},
+		{"@hourly", "2026-10-19 10:30", "2026-10-19 11:00"},
+		{"@daily", "2026-10-19 10:30", "2026-10-20 09:00"},
+		{"@weekly", "2026-10-19 10:30", "2026-10-26 09:00"},
+		{"@monthly", "2026-10-19 10:30", "2026-11-01 09:00"},
+	}
+
+	for _, tt := range tests {
+		s, err := parseCron(tt.expr)
+		if err != nil {
+			t.Errorf("parseCron(%q) error: %v", tt.expr, err)
+			continue
+		}
+		if got := s.Next(utc(tt.from)); !got.Equal(utc(tt.want)) {
+			t.Errorf("%q.Next(%s) = %s, want %s", tt.expr, tt.from, got.Format("2006-01-02 15:04"), tt.want)
+		}
+	}
+}
+
+func TestCronNextInLocation(t *testing.T) {
+	loc, err := time.LoadLocation("Europe/Istanbul")
+	if err != nil {
+		t.Fatal(err)
+	}
+	s, err := parseCron("0 9 * * *")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// 07:00 UTC is 10:00 in Istanbul, so the next run is tomorrow at 09:00 local.
+	from := time.Date(2026, 10, 19, 7, 0, 0, 0, time.UTC).In(loc)
+	want := time.Date(2026, 10, 20, 9, 0, 0, 0, loc)
+	if got := s.Next(from); !got.Equal(want) {
+		t.Errorf("Next(%s) = %s, want %s", from, got, want)
+	}
+}
+
+func equalInts(a, b []int) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,154 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"sync"
+)
+
+// --- Status Messages ---
+
+// statusMessagesLimit caps how many cards a target remembers a message for.
+const statusMessagesLimit = 1000
+
+// statusMessages keeps one Zulip message per card up to date. The first
+// event of a card is posted through the Zulip API as the bot, and later card
+// events edit that message instead of posting again. Comments are still
+// posted as new messages. Only Zulip can do this: Google Chat webhooks
+// cannot edit messages, and Gotify has no edit API.
+type statusMessages struct {
+	mu     sync.Mutex // Held while sending, so events on one card apply in order
+	stream string
+	topic  string
+	ids    map[string]int // cardKey -> Zulip message ID
+	order  []string       // Cards oldest first, to forget beyond the limit
+}
+
+// loadStatusMessages sets up status messages for a target. It returns nil
+// when they are disabled. Stream and topic are read from the target URL.
+func loadStatusMessages(tc targetConfig, targetType TargetType) (*statusMessages, error) {
+	if tc.StatusMessages && targetType != TargetZulip {
+		return nil, fmt.Errorf("status messages are only supported for zulip targets")
+	}
+	if targetType != TargetZulip || !(tc.StatusMessages || os.Getenv("STATUS_MESSAGES") == "true") {
+		return nil, nil
+	}
+	if os.Getenv("ZULIP_SITE") == "" || os.Getenv("ZULIP_BOT_EMAIL") == "" || os.Getenv("ZULIP_BOT_API_KEY") == "" {
+		return nil, fmt.Errorf("status messages need ZULIP_SITE, ZULIP_BOT_EMAIL and ZULIP_BOT_API_KEY")
+	}
+
+	u, err := url.Parse(tc.URL)
+	if err != nil {
+		return nil, err
+	}
+	q := u.Query()
+	if q.Get("stream") == "" {
+		return nil, fmt.Errorf("status messages need stream= in the url")
+	}
+	return &statusMessages{
+		stream: q.Get("stream"),
+		topic:  q.Get("topic"),
+		ids:    make(map[string]int),
+	}, nil
+}
+
+// handles reports whether ev updates a card's status message. Comments and
+// events without an identifiable card go through the webhook as usual.
+func (s *statusMessages) handles(events []event) bool {
+	if len(events) != 1 {
+		return false
+	}
+	f := events[0].Payload
+	return strings.HasPrefix(strings.ToLower(f.Action), "card_") && cardKey(f) != ""
+}
+
+// deliver edits the card's message, or posts it when there is none yet or
+// it can no longer be edited (e.g. after Zulip's edit time limit).
+func (s *statusMessages) deliver(ctx context.Context, t target, ev event, body []byte) (*upstreamResponse, error) {
+	var msg ZulipPayload
+	if err := json.Unmarshal(body, &msg); err != nil {
+		return nil, fmt.Errorf("status message: %w", err)
+	}
+	key := cardKey(ev.Payload)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if id, ok := s.ids[key]; ok {
+		resp, err := zulipAPI(ctx, t.Client, http.MethodPatch, fmt.Sprintf("/api/v1/messages/%d", id), url.Values{
+			"content": {msg.Content},
+		})
+		if err != nil {
+			return nil, err
+		}
+		if resp.StatusCode < 300 {
+			log.Printf("[INFO] Edited status message %d for %s on %s", id, key, t.Name)
+			return resp, nil
+		}
+		log.Printf("[WARN] Cannot edit status message %d for %s on %s, posting a new one: %d %s", id, key, t.Name, resp.StatusCode, resp.Body)
+	}
+
+	form := url.Values{
+		"type":    {"stream"},
+		"to":      {s.stream},
+		"content": {msg.Content},
+	}
+	if s.topic != "" {
+		form.Set("topic", s.topic)
+	}
+	resp, err := zulipAPI(ctx, t.Client, http.MethodPost, "/api/v1/messages", form)
+	if err != nil || resp.StatusCode >= 300 {
+		return resp, err
+	}
+
+	var sent struct {
+		ID int `json:"id"`
+	}
+	if err := json.Unmarshal(resp.Body, &sent); err == nil && sent.ID != 0 {
+		s.remember(key, sent.ID)
+	}
+	return resp, nil
+}
+
+// remember records the message of a card. Callers hold s.mu.
+func (s *statusMessages) remember(key string, id int) {
+	if _, ok := s.ids[key]; !ok {
+		s.order = append(s.order, key)
+	}
+	s.ids[key] = id
+	if len(s.order) > statusMessagesLimit {
+		delete(s.ids, s.order[0])
+		s.order = s.order[1:]
+	}
+}
+
+// zulipAPI calls the Zulip REST API at ZULIP_SITE as the bot configured with
+// ZULIP_BOT_EMAIL and ZULIP_BOT_API_KEY.
+func zulipAPI(ctx context.Context, client *http.Client, method, path string, form url.Values) (*upstreamResponse, error) {
+	endpoint := strings.TrimSuffix(os.Getenv("ZULIP_SITE"), "/") + path
+	req, err := http.NewRequestWithContext(ctx, method, endpoint, strings.NewReader(form.Encode()))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("User-Agent", "Fizzy-Proxy/1.0")
+	req.SetBasicAuth(os.Getenv("ZULIP_BOT_EMAIL"), os.Getenv("ZULIP_BOT_API_KEY"))
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		log.Printf("failed to read Zulip API response body: %v", err)
+	}
+	return &upstreamResponse{StatusCode: resp.StatusCode, Body: body}, nil
+}
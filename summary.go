@@ -0,0 +1,296 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// --- Scheduled Summaries ---
+
+// boardStats counts what happened on one board during a summary period.
+type boardStats struct {
+	Name    string
+	URL     string
+	Created int
+	Closed  int
+	Moved   int
+	Other   int
+}
+
+// summarizer accumulates events for a target and posts a periodic summary
+// on a cron schedule.
+type summarizer struct {
+	mu         sync.Mutex
+	schedule   *cronSchedule
+	loc        *time.Location // Time zone the schedule is evaluated in
+	only       bool           // Suppress live notifications and only post summaries
+	cat        *catalog       // Wording for board and commenter fallbacks
+	since      time.Time
+	boards     map[string]*boardStats
+	commenters map[string]int
+	total      int
+	done       chan struct{}
+	stopOnce   sync.Once
+}
+
+func newSummarizer(schedule *cronSchedule, loc *time.Location, only bool, cat *catalog) *summarizer {
+	s := &summarizer{schedule: schedule, loc: loc, only: only, cat: cat, done: make(chan struct{})}
+	s.reset(time.Now().In(loc))
+	return s
+}
+
+// reset starts a new period. Callers hold s.mu (or own s exclusively).
+func (s *summarizer) reset(now time.Time) {
+	s.since = now
+	s.boards = make(map[string]*boardStats)
+	s.commenters = make(map[string]int)
+	s.total = 0
+}
+
+// Record counts f towards the current period.
+func (s *summarizer) Record(f FizzyPayload) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.total++
+
+	name := f.Board.Name
+	if name == "" {
+		name = s.cat.text("other_board")
+	}
+	b, ok := s.boards[name]
+	if !ok {
+		b = &boardStats{Name: name, URL: resolveFizzyURL(FizzyPayload{Board: f.Board})}
+		s.boards[name] = b
+	}
+
+	switch strings.ToLower(f.Action) {
+	case "card_created", "card_published":
+		b.Created++
+	case "card_closed":
+		b.Closed++
+	case "card_moved", "card_board_changed":
+		b.Moved++
+	case "comment_created":
+		s.commenters[actorName(s.cat, f)]++
+		b.Other++
+	default:
+		b.Other++
+	}
+}
+
+// summary is a snapshot of one finished period, ready to be rendered.
+type summary struct {
+	Since      time.Time
+	Until      time.Time
+	Boards     []boardStats
+	Commenters []commenterCount
+	Total      int
+}
+
+type commenterCount struct {
+	Name  string
+	Count int
+}
+
+// topCommentersLimit caps how many commenters a summary lists.
+const topCommentersLimit = 5
+
+// snapshot returns the current period and starts a new one.
+func (s *summarizer) snapshot(now time.Time) summary {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	sum := summary{Since: s.since, Until: now, Total: s.total}
+	for _, b := range s.boards {
+		sum.Boards = append(sum.Boards, *b)
+	}
+	sort.Slice(sum.Boards, func(i, j int) bool { return sum.Boards[i].Name < sum.Boards[j].Name })
+
+	for name, n := range s.commenters {
+		sum.Commenters = append(sum.Commenters, commenterCount{Name: name, Count: n})
+	}
+	sort.Slice(sum.Commenters, func(i, j int) bool {
+		if sum.Commenters[i].Count != sum.Commenters[j].Count {
+			return sum.Commenters[i].Count > sum.Commenters[j].Count
+		}
+		return sum.Commenters[i].Name < sum.Commenters[j].Name
+	})
+	if len(sum.Commenters) > topCommentersLimit {
+		sum.Commenters = sum.Commenters[:topCommentersLimit]
+	}
+
+	s.reset(now)
+	return sum
+}
+
+// run posts a summary to t every time the schedule fires. Empty periods are
+// skipped so quiet days don't produce noise.
+func (s *summarizer) run(t target) {
+	for {
+		now := time.Now().In(s.loc)
+		next := s.schedule.Next(now)
+		if next.IsZero() {
+			log.Printf("warning: summary schedule for %s never fires", t.Name)
+			return
+		}
+		timer := time.NewTimer(time.Until(next))
+		select {
+		case <-timer.C:
+		case <-s.done:
+			timer.Stop()
+			return
+		}
+
+		sum := s.snapshot(time.Now().In(s.loc))
+		if sum.Total == 0 {
+			continue
+		}
+
+		body, err := translateSummary(t, sum)
+		if err != nil {
+			log.Printf("summary translation error for %s: %v", t.Name, err)
+			continue
+		}
+		resp, err := deliver(context.Background(), t, nil, body)
+		if err != nil {
+			log.Printf("summary delivery error (%s): %v", t.Name, err)
+			continue
+		}
+		if resp.StatusCode >= 300 {
+			log.Printf("summary delivery to %s failed with status %d", t.Name, resp.StatusCode)
+		}
+	}
+}
+
+// Stop ends the schedule loop. Events counted so far are discarded.
+func (s *summarizer) Stop() {
+	s.stopOnce.Do(func() { close(s.done) })
+}
+
+// loadSummarizer builds the summarizer for a target from its summary
+// schedule, evaluated in the target's time zone. It returns nil when no
+// schedule is configured.
+func loadSummarizer(tc targetConfig, loc *time.Location, cat *catalog) (*summarizer, error) {
+	if tc.SummarySchedule == "" {
+		return nil, nil
+	}
+	schedule, err := parseCron(tc.SummarySchedule)
+	if err != nil {
+		return nil, err
+	}
+	return newSummarizer(schedule, loc, tc.SummaryOnly, cat), nil
+}
+
+// --- Summary Rendering ---
+
+func summaryTitle(cat *catalog, sum summary) string {
+	return cat.text("summary_title", sum.Since.Format(cat.text("summary_date")))
+}
+
+func boardLine(cat *catalog, b boardStats) string {
+	return cat.text("summary_board", b.Created, b.Closed, b.Moved, b.Other)
+}
+
+func commentersLine(sum summary) string {
+	parts := make([]string, 0, len(sum.Commenters))
+	for _, c := range sum.Commenters {
+		parts = append(parts, fmt.Sprintf("%s (%d)", c.Name, c.Count))
+	}
+	return strings.Join(parts, ", ")
+}
+
+func buildSummaryMessage(cat *catalog, sum summary) string {
+	var sb strings.Builder
+	sb.WriteString(fmt.Sprintf("### 📊 %s", summaryTitle(cat, sum)))
+	sb.WriteString("\n\n" + cat.text("summary_total", sum.Total))
+
+	for _, b := range sum.Boards {
+		name := b.Name
+		if b.URL != "" {
+			name = fmt.Sprintf("[%s](%s)", b.Name, b.URL)
+		}
+		sb.WriteString(fmt.Sprintf("\n- **%s**: %s", name, boardLine(cat, b)))
+	}
+
+	if len(sum.Commenters) > 0 {
+		sb.WriteString("\n\n" + cat.text("top_commenters") + ": ")
+		sb.WriteString(commentersLine(sum))
+	}
+
+	return sb.String()
+}
+
+// translateSummary renders a summary in the target's format.
+func translateSummary(t target, sum summary) ([]byte, error) {
+	switch t.Type {
+	case TargetZulip:
+		return json.Marshal(ZulipPayload{Content: buildSummaryMessage(t.Catalog, sum)})
+	case TargetGotify:
+		return json.Marshal(GotifyPayload{
+			Message:  buildSummaryMessage(t.Catalog, sum),
+			Title:    summaryTitle(t.Catalog, sum),
+			Priority: 5,
+			Extras: map[string]interface{}{
+				"client::display": map[string]string{
+					"contentType": "text/markdown",
+				},
+			},
+		})
+	case TargetGoogleChat:
+		return translateSummaryToGoogleChat(t.Catalog, sum)
+	default:
+		return json.Marshal(sum)
+	}
+}
+
+func translateSummaryToGoogleChat(cat *catalog, sum summary) ([]byte, error) {
+	var sections []CardSection
+
+	var boardWidgets []Widget
+	for _, b := range sum.Boards {
+		text := b.Name
+		if b.URL != "" {
+			text = chatLink(b.URL, b.Name)
+		}
+		boardWidgets = append(boardWidgets, Widget{
+			DecoratedText: &DecoratedText{
+				TopLabel:  boardLine(cat, b),
+				Text:      text,
+				StartIcon: &Icon{KnownIcon: "TICKET"},
+			},
+		})
+	}
+	sections = append(sections, CardSection{Header: cat.text("summary_boards"), Widgets: boardWidgets})
+
+	if len(sum.Commenters) > 0 {
+		sections = append(sections, CardSection{
+			Header: cat.text("top_commenters"),
+			Widgets: []Widget{
+				{TextParagraph: &TextParagraph{Text: commentersLine(sum)}},
+			},
+		})
+	}
+
+	card := CardV2{
+		CardID: fmt.Sprintf("fizzy-summary-%d", time.Now().UnixNano()),
+		Card: Card{
+			Header: CardHeader{
+				Title:    summaryTitle(cat, sum),
+				Subtitle: cat.text("summary_events", sum.Total),
+			},
+			Sections: sections,
+		},
+	}
+
+	return json.Marshal(GoogleChatPayload{
+		Text:    "📊 " + summaryTitle(cat, sum),
+		CardsV2: []CardV2{card},
+	})
+}
@@ -0,0 +1,87 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+	"text/template"
+)
+
+// --- Message Templates ---
+
+// templateFuncs are the helpers available to target templates, worded in
+// the target's locale. Each takes the payload, e.g. {{verb .}} or {{url .}}.
+func templateFuncs(cat *catalog) template.FuncMap {
+	return template.FuncMap{
+		"verb": func(f FizzyPayload) string {
+			verb, _ := prettyAction(cat, f)
+			return verb
+		},
+		"emoji": func(f FizzyPayload) string {
+			_, emoji := prettyAction(cat, f)
+			return emoji
+		},
+		"actor": func(f FizzyPayload) string {
+			return actorName(cat, f)
+		},
+		"subject": func(f FizzyPayload) string {
+			return resolveSubject(cat, f)
+		},
+		"url": resolveFizzyURL,
+		"boardName": func(f FizzyPayload) string {
+			return f.Board.Name
+		},
+		// json renders a value as a JSON literal, for Google Chat card templates.
+		"json": func(v interface{}) (string, error) {
+			data, err := json.Marshal(v)
+			return string(data), err
+		},
+	}
+}
+
+// loadTemplate parses the target's message template, given inline or as a
+// file. It returns nil when the target uses the built-in formatting.
+func loadTemplate(tc targetConfig, cat *catalog) (*template.Template, error) {
+	text := tc.Template
+	if tc.TemplateFile != "" {
+		if text != "" {
+			return nil, fmt.Errorf("set either template or template_file, not both")
+		}
+		data, err := os.ReadFile(tc.TemplateFile)
+		if err != nil {
+			return nil, err
+		}
+		text = string(data)
+	}
+	if strings.TrimSpace(text) == "" {
+		return nil, nil
+	}
+	return template.New(tc.Identifier).Funcs(templateFuncs(cat)).Option("missingkey=error").Parse(text)
+}
+
+// translateWithTemplate renders a single event with the target's template.
+// For Zulip and Gotify the template produces the markdown message; for
+// Google Chat it produces the complete JSON body, e.g. {"cardsV2": [...]}.
+func translateWithTemplate(t target, f FizzyPayload) ([]byte, error) {
+	var buf bytes.Buffer
+	if err := t.Template.Execute(&buf, f); err != nil {
+		return nil, err
+	}
+	msg := strings.TrimSpace(buf.String())
+
+	switch t.Type {
+	case TargetZulip:
+		return json.Marshal(ZulipPayload{Content: msg})
+	case TargetGotify:
+		return gotifyMessage(t.Catalog, f, msg)
+	case TargetGoogleChat:
+		if !json.Valid([]byte(msg)) {
+			return nil, fmt.Errorf("template: google chat template must produce JSON")
+		}
+		return []byte(msg), nil
+	default:
+		return []byte(msg), nil
+	}
+}
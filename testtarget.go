@@ -0,0 +1,189 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"text/tabwriter"
+	"time"
+)
+
+// --- Test Events ---
+
+// testResult reports how one synthetic event was handled.
+type testResult struct {
+	Action  string `json:"action"`
+	EventID string `json:"event_id"`
+	Outcome string `json:"outcome"`
+	Status  int    `json:"status,omitempty"` // Upstream HTTP status once sent
+	Error   string `json:"error,omitempty"`
+}
+
+// OK reports whether the event was sent and accepted upstream.
+func (r testResult) OK() bool {
+	return r.Error == "" && r.Status >= 200 && r.Status < 300
+}
+
+// testPayload builds a realistic Fizzy payload for action. The card ID is
+// unique per run, so repeated tests are not dropped as duplicates, and the
+// card has no number, so test events are never taken for a real card when
+// aggregating, editing status messages or searching the history.
+func testPayload(action, runID string) FizzyPayload {
+	const title = "Test card from fizzy-webhook-proxy"
+	cardID := "test-card-" + runID
+	cardURL := "https://fizzy.example.com/0000001/cards/" + cardID
+
+	f := FizzyPayload{
+		ID:      "evt_test_" + runID,
+		Action:  action,
+		Creator: FizzyUser{Name: "Fizzy Webhook Proxy"},
+		Board:   FizzyBoard{ID: "test-board", Name: "Test Board"},
+		Eventable: FizzyEventable{
+			ID:    cardID,
+			Title: title,
+			URL:   cardURL,
+		},
+	}
+
+	switch action {
+	case "comment_created":
+		f.Eventable = FizzyEventable{
+			ID:   "test-comment-" + runID,
+			Card: &FizzyCard{Title: title},
+			URL:  cardURL + "#comment_test",
+		}
+		f.Eventable.Body.PlainText = "This is a test comment. If you can read it, the target works."
+		f.Card = &FizzyCard{Title: title}
+	case "card_assigned", "card_unassigned":
+		f.Assignee = &FizzyUser{Name: "Test Assignee"}
+	case "card_moved":
+		f.Column = &FizzyColumn{Name: "In Progress"}
+	case "card_closed":
+		f.Column = &FizzyColumn{Name: "Done"}
+	case "card_board_changed":
+		f.Board.Name = "Another Test Board"
+	}
+	return f
+}
+
+// sendTestEvents runs one synthetic event per known action through the full
+// pipeline of t. Held or queued events are delivered later as usual.
+func sendTestEvents(ctx context.Context, t target) []testResult {
+	runID := fmt.Sprintf("%x", time.Now().UnixNano())
+	results := make([]testResult, 0, len(knownActions))
+
+	for _, action := range knownActions {
+		payload := testPayload(action, runID)
+		raw, err := json.Marshal(payload)
+		if err != nil {
+			results = append(results, testResult{Action: action, Error: err.Error()})
+			continue
+		}
+
+		ev := event{ID: newEventID(), Payload: payload, Raw: raw, Received: time.Now(), Synthetic: true}
+		outcome, resp, err := processEvent(ctx, t, ev)
+		r := testResult{Action: action, EventID: ev.ID, Outcome: outcome}
+		if resp != nil {
+			r.Status = resp.StatusCode
+		}
+		if err != nil {
+			r.Error = err.Error()
+		}
+		results = append(results, r)
+	}
+	return results
+}
+
+// awaitTestDeliveries waits up to timeout for held or queued test events to
+// show up in the delivery log, filling in their upstream status.
+func awaitTestDeliveries(identifier string, results []testResult, timeout time.Duration) {
+	pending := make(map[string]*testResult)
+	for i := range results {
+		if r := &results[i]; r.Error == "" && r.Status == 0 && r.Outcome != outcomeDisabled && r.Outcome != outcomeDuplicate && r.Outcome != outcomeSummaryOnly {
+			pending[r.EventID] = r
+		}
+	}
+
+	deadline := time.Now().Add(timeout)
+	for len(pending) > 0 && time.Now().Before(deadline) {
+		time.Sleep(200 * time.Millisecond)
+		for _, d := range deliveries.recent(identifier) {
+			for _, ev := range d.Events {
+				for _, id := range ev.IDs() {
+					if r, ok := pending[id]; ok {
+						r.Status, r.Error = d.Status, d.Error
+						delete(pending, id)
+					}
+				}
+			}
+		}
+	}
+}
+
+// runTestTarget implements "fizzy-webhook-proxy test-target IDENTIFIER". It
+// loads the configuration like the server does and sends the test events
+// from this process; it exits non-zero unless every event was accepted.
+func runTestTarget(args []string) int {
+	fs := flag.NewFlagSet("test-target", flag.ContinueOnError)
+	wait := fs.Duration("wait", time.Minute, "how long to wait for held or queued events to be delivered")
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "usage: fizzy-webhook-proxy test-target [--wait 1m] IDENTIFIER")
+		fs.PrintDefaults()
+	}
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+	if fs.NArg() != 1 {
+		fs.Usage()
+		return 2
+	}
+	identifier := fs.Arg(0)
+
+	targets, errs := loadConfiguredTargets()
+	for _, err := range errs {
+		fmt.Fprintf(os.Stderr, "warning: skipping %v\n", err)
+	}
+
+	var t *target
+	for _, candidate := range targets {
+		if candidate.Identifier == identifier {
+			t = candidate
+		}
+	}
+	if t == nil {
+		fmt.Fprintf(os.Stderr, "target %q not found\n", identifier)
+		return 1
+	}
+
+	results := sendTestEvents(context.Background(), *t)
+	awaitTestDeliveries(t.Identifier, results, *wait)
+
+	failed := false
+	tw := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(tw, "ACTION\tOUTCOME\tRESULT")
+	for _, r := range results {
+		result, ok := "ok", false
+		switch {
+		case r.Error != "":
+			result = r.Error
+		case r.Outcome == outcomeSummaryOnly:
+			result, ok = "counted for the next summary", true
+		case r.Status == 0:
+			result = "not delivered"
+		case !r.OK():
+			result = fmt.Sprintf("upstream answered %d", r.Status)
+		default:
+			ok = true
+		}
+		failed = failed || !ok
+		fmt.Fprintf(tw, "%s\t%s\t%s\n", r.Action, r.Outcome, result)
+	}
+	tw.Flush()
+
+	if failed {
+		return 1
+	}
+	return 0
+}
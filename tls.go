@@ -0,0 +1,169 @@
+package main
+
+import (
+	"crypto/tls"
+	"fmt"
+	"log"
+	"net"
+	"net/http"
+	"os"
+	"sync"
+	"time"
+
+	"golang.org/x/crypto/acme"
+	"golang.org/x/crypto/acme/autocert"
+)
+
+// --- TLS ---
+
+// defaultACMECacheDir stores ACME account keys and certificates.
+const defaultACMECacheDir = "/var/lib/fizzy-webhook-proxy/acme"
+
+// listenAndServe serves handler on port: over HTTPS with TLS_CERT_FILE and
+// TLS_KEY_FILE, over HTTPS with certificates from ACME for ACME_DOMAINS, or
+// over plain HTTP when neither is configured. With HTTP_REDIRECT_PORT set,
+// plain HTTP on that port redirects to HTTPS (and answers ACME challenges).
+func listenAndServe(port string, handler http.Handler) error {
+	certFile, keyFile := os.Getenv("TLS_CERT_FILE"), os.Getenv("TLS_KEY_FILE")
+	domains := splitList(os.Getenv("ACME_DOMAINS"))
+	redirectPort := os.Getenv("HTTP_REDIRECT_PORT")
+
+	srv := &http.Server{
+		Addr:              ":" + port,
+		Handler:           handler,
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+
+	switch {
+	case len(domains) > 0 && (certFile != "" || keyFile != ""):
+		return fmt.Errorf("set either TLS_CERT_FILE/TLS_KEY_FILE or ACME_DOMAINS, not both")
+
+	case len(domains) > 0:
+		manager, err := newACMEManager(domains)
+		if err != nil {
+			return err
+		}
+		srv.TLSConfig = manager.TLSConfig()
+		if redirectPort != "" {
+			go serveRedirect(redirectPort, manager.HTTPHandler(redirectToHTTPS(port)))
+		}
+		log.Printf("TLS enabled with ACME certificates for %v", domains)
+		return srv.ListenAndServeTLS("", "")
+
+	case certFile != "" || keyFile != "":
+		if certFile == "" || keyFile == "" {
+			return fmt.Errorf("TLS_CERT_FILE and TLS_KEY_FILE must be set together")
+		}
+		certs, err := newCertReloader(certFile, keyFile)
+		if err != nil {
+			return err
+		}
+		srv.TLSConfig = &tls.Config{GetCertificate: certs.GetCertificate}
+		if redirectPort != "" {
+			go serveRedirect(redirectPort, redirectToHTTPS(port))
+		}
+		log.Printf("TLS enabled with certificate %s", certFile)
+		return srv.ListenAndServeTLS("", "")
+
+	default:
+		if redirectPort != "" {
+			log.Printf("warning: HTTP_REDIRECT_PORT is ignored without TLS")
+		}
+		return srv.ListenAndServe()
+	}
+}
+
+// newACMEManager obtains and renews certificates automatically. The
+// directory defaults to Let's Encrypt; ACME_DIRECTORY_URL and ACME_CA_FILE
+// point it at another CA, e.g. a local Pebble for testing.
+func newACMEManager(domains []string) (*autocert.Manager, error) {
+	manager := &autocert.Manager{
+		Prompt:     autocert.AcceptTOS,
+		HostPolicy: autocert.HostWhitelist(domains...),
+		Cache:      autocert.DirCache(envOrDefault("ACME_CACHE_DIR", defaultACMECacheDir)),
+		Email:      os.Getenv("ACME_EMAIL"),
+	}
+
+	directoryURL, caFile := os.Getenv("ACME_DIRECTORY_URL"), os.Getenv("ACME_CA_FILE")
+	if directoryURL != "" || caFile != "" {
+		client, err := newHTTPClient(clientConfig{Timeout: 30 * time.Second, CAFile: caFile})
+		if err != nil {
+			return nil, fmt.Errorf("ACME client: %w", err)
+		}
+		manager.Client = &acme.Client{DirectoryURL: directoryURL, HTTPClient: client}
+	}
+	return manager, nil
+}
+
+// serveRedirect runs the plain HTTP listener next to the HTTPS server.
+func serveRedirect(port string, handler http.Handler) {
+	log.Printf("redirecting HTTP on :%s to HTTPS", port)
+	srv := &http.Server{Addr: ":" + port, Handler: handler, ReadHeaderTimeout: 10 * time.Second}
+	if err := srv.ListenAndServe(); err != nil {
+		log.Fatalf("redirect server error: %v", err)
+	}
+}
+
+// redirectToHTTPS sends clients to the same URL on the HTTPS port. 308 keeps
+// the method and body, so webhook POSTs are retried as POSTs.
+func redirectToHTTPS(httpsPort string) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		host := r.Host
+		if h, _, err := net.SplitHostPort(host); err == nil {
+			host = h
+		}
+		if httpsPort != "443" {
+			host = net.JoinHostPort(host, httpsPort)
+		}
+		http.Redirect(w, r, "https://"+host+r.URL.RequestURI(), http.StatusPermanentRedirect)
+	})
+}
+
+// certReloader serves a certificate from files and picks up renewals (e.g.
+// by certbot) without a restart. The files are checked at most once a minute.
+type certReloader struct {
+	certFile, keyFile string
+
+	mu      sync.Mutex
+	cert    *tls.Certificate
+	modTime time.Time
+	checked time.Time
+}
+
+func newCertReloader(certFile, keyFile string) (*certReloader, error) {
+	c := &certReloader{certFile: certFile, keyFile: keyFile}
+	if err := c.load(); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+func (c *certReloader) load() error {
+	info, err := os.Stat(c.certFile)
+	if err != nil {
+		return fmt.Errorf("TLS certificate: %w", err)
+	}
+	cert, err := tls.LoadX509KeyPair(c.certFile, c.keyFile)
+	if err != nil {
+		return fmt.Errorf("TLS certificate: %w", err)
+	}
+	c.cert, c.modTime = &cert, info.ModTime()
+	return nil
+}
+
+func (c *certReloader) GetCertificate(*tls.ClientHelloInfo) (*tls.Certificate, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if time.Since(c.checked) > time.Minute {
+		c.checked = time.Now()
+		if info, err := os.Stat(c.certFile); err == nil && !info.ModTime().Equal(c.modTime) {
+			if err := c.load(); err != nil {
+				log.Printf("warning: keeping previous certificate: %v", err)
+			} else {
+				log.Printf("reloaded TLS certificate %s", c.certFile)
+			}
+		}
+	}
+	return c.cert, nil
+}
@@ -0,0 +1,88 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// --- User Directory ---
+
+// directoryUser is how one Fizzy user is known on the chat platforms, and
+// where their direct messages go.
+type directoryUser struct {
+	Zulip      string `json:"zulip,omitempty"`       // Full name, or "Name|ID" when names are ambiguous
+	GoogleChat string `json:"google_chat,omitempty"` // User resource name, e.g. "users/123456789"
+	Avatar     string `json:"avatar,omitempty"`      // Picture URL for Google Chat cards
+
+	// Personal destinations, see dm.go
+	ZulipPM string `json:"zulip_pm,omitempty"` // Zulip email or user ID
+	Gotify  string `json:"gotify,omitempty"`   // App token on GOTIFY_SERVER, or a full message URL
+	Ntfy    string `json:"ntfy,omitempty"`     // Topic on NTFY_SERVER, or a full topic URL
+	Email   string `json:"email,omitempty"`
+}
+
+// userDirectory maps Fizzy user names (compared case-insensitively) to
+// their chat identities. It is read from USERS_FILE:
+//
+//	{
+//	  "Jane Doe": {"zulip": "Jane Doe", "google_chat": "users/123456789", "ntfy": "jane-fizzy"}
+//	}
+type userDirectory map[string]directoryUser
+
+// users is the directory loaded at startup; empty without USERS_FILE.
+var users userDirectory
+
+// loadUserDirectory reads USERS_FILE into users.
+func loadUserDirectory() error {
+	path := os.Getenv("USERS_FILE")
+	if path == "" {
+		users = nil
+		return nil
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("USERS_FILE: %w", err)
+	}
+	var entries userDirectory
+	if err := json.Unmarshal(data, &entries); err != nil {
+		return fmt.Errorf("USERS_FILE %s: %w", path, err)
+	}
+	users = make(userDirectory, len(entries))
+	for name, u := range entries {
+		if err := checkDirectDestinations(u); err != nil {
+			return fmt.Errorf("USERS_FILE: %s: %w", name, err)
+		}
+		users[strings.ToLower(strings.TrimSpace(name))] = u
+	}
+	return nil
+}
+
+// lookup returns the directory entry for a Fizzy user name.
+func (d userDirectory) lookup(name string) (directoryUser, bool) {
+	u, ok := d[strings.ToLower(strings.TrimSpace(name))]
+	return u, ok
+}
+
+// mentions returns the mention markup per lowercased Fizzy name for a target
+// type, e.g. "@**Jane Doe**" for Zulip. Types without mentions get nil.
+func (d userDirectory) mentions(targetType TargetType) map[string]string {
+	mentions := make(map[string]string)
+	for name, u := range d {
+		switch {
+		case targetType == TargetZulip && u.Zulip != "":
+			mentions[name] = "@**" + u.Zulip + "**"
+		case targetType == TargetGoogleChat && u.GoogleChat != "":
+			id := u.GoogleChat
+			if !strings.HasPrefix(id, "users/") {
+				id = "users/" + id
+			}
+			mentions[name] = "<" + id + ">"
+		}
+	}
+	if len(mentions) == 0 {
+		return nil
+	}
+	return mentions
+}
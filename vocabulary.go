@@ -0,0 +1,125 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// --- Action Vocabulary ---
+
+// vocabulary adjusts a target's wording on top of its locale: verbs for new
+// or renamed actions, emojis, and the column names that mean "completed" or
+// "postponed" on the team's boards. It is read from a JSON file:
+//
+//	{
+//	  "verbs": {"card_pinned": "pinned the card"},
+//	  "emojis": {"card_pinned": "📌", "card_closed": "🔒"},
+//	  "completed_columns": ["Done", "Shipped"],
+//	  "postponed_columns": ["Icebox"]
+//	}
+type vocabulary struct {
+	Verbs            map[string]string `json:"verbs,omitempty"`
+	Emojis           map[string]string `json:"emojis,omitempty"`
+	CompletedColumns []string          `json:"completed_columns,omitempty"`
+	PostponedColumns []string          `json:"postponed_columns,omitempty"`
+}
+
+func loadVocabularyFile(path string) (vocabulary, error) {
+	var v vocabulary
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return v, err
+	}
+	if err := json.Unmarshal(data, &v); err != nil {
+		return v, fmt.Errorf("%s: %w", path, err)
+	}
+	return v, nil
+}
+
+// parseEmojis reads "action=emoji" pairs, e.g. "card_closed=🔒,card_pinned=📌".
+func parseEmojis(s string) (map[string]string, error) {
+	items := splitList(s)
+	if len(items) == 0 {
+		return nil, nil
+	}
+	emojis := make(map[string]string, len(items))
+	for _, item := range items {
+		action, emoji, ok := strings.Cut(item, "=")
+		action, emoji = strings.TrimSpace(action), strings.TrimSpace(emoji)
+		if !ok || action == "" || emoji == "" {
+			return nil, fmt.Errorf("invalid emoji %q (expected action=emoji)", item)
+		}
+		emojis[action] = emoji
+	}
+	return emojis, nil
+}
+
+// targetVocabularies returns the vocabularies that apply to a target, least
+// specific first: the global VOCABULARY_FILE, the global EMOJIS and column
+// settings, the target's vocabulary file and the target's own settings.
+func targetVocabularies(tc targetConfig) ([]vocabulary, error) {
+	var vocabularies []vocabulary
+
+	if path := os.Getenv("VOCABULARY_FILE"); path != "" {
+		v, err := loadVocabularyFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("vocabulary: %w", err)
+		}
+		vocabularies = append(vocabularies, v)
+	}
+
+	emojis, err := parseEmojis(os.Getenv("EMOJIS"))
+	if err != nil {
+		return nil, fmt.Errorf("EMOJIS: %w", err)
+	}
+	vocabularies = append(vocabularies, vocabulary{
+		Emojis:           emojis,
+		CompletedColumns: splitList(os.Getenv("COMPLETED_COLUMNS")),
+		PostponedColumns: splitList(os.Getenv("POSTPONED_COLUMNS")),
+	})
+
+	if tc.VocabularyFile != "" {
+		v, err := loadVocabularyFile(tc.VocabularyFile)
+		if err != nil {
+			return nil, fmt.Errorf("vocabulary: %w", err)
+		}
+		vocabularies = append(vocabularies, v)
+	}
+
+	return append(vocabularies, vocabulary{
+		Emojis:           tc.Emojis,
+		CompletedColumns: tc.CompletedColumns,
+		PostponedColumns: tc.PostponedColumns,
+	}), nil
+}
+
+// withVocabulary returns a copy of c with v applied. Column lists replace
+// the previous ones; verbs and emojis are merged per action.
+func (c *catalog) withVocabulary(v vocabulary) *catalog {
+	merged := *c
+	merged.Verbs = mergeEntries(c.Verbs, v.Verbs)
+	merged.Emojis = mergeEntries(c.Emojis, v.Emojis)
+	if len(v.CompletedColumns) > 0 {
+		merged.CompletedColumns = v.CompletedColumns
+	}
+	if len(v.PostponedColumns) > 0 {
+		merged.PostponedColumns = v.PostponedColumns
+	}
+	return &merged
+}
+
+func mergeEntries(base, overrides map[string]string) map[string]string {
+	if len(overrides) == 0 {
+		return base
+	}
+	merged := make(map[string]string, len(base)+len(overrides))
+	for k, v := range base {
+		merged[k] = v
+	}
+	for k, v := range overrides {
+		merged[strings.ToLower(k)] = v
+	}
+	return merged
+}